@@ -0,0 +1,41 @@
+package cheapjson
+
+// the count of the Values of a slab
+const slabSize = 256
+
+// arena allocates the Values from the slabs, and releases
+// all of them at once by reset.
+type arena struct {
+	slabs [][]Value
+	// the current slab, and the count of the Values used of it
+	slab int
+	used int
+}
+
+func (a *arena) alloc() *Value {
+	if len(a.slabs) == 0 || a.used == slabSize {
+		if len(a.slabs) > 0 {
+			a.slab++
+		}
+		if a.slab == len(a.slabs) {
+			a.slabs = append(a.slabs, make([]Value, slabSize))
+		}
+		a.used = 0
+	}
+	v := &a.slabs[a.slab][a.used]
+	a.used++
+	return v
+}
+
+// reset clears the Values allocated, so they do not hold the
+// trees of the previous texts.
+func (a *arena) reset() {
+	for i := 0; i < a.slab; i++ {
+		clear(a.slabs[i])
+	}
+	if len(a.slabs) > 0 {
+		clear(a.slabs[a.slab][:a.used])
+	}
+	a.slab = 0
+	a.used = 0
+}
@@ -0,0 +1,214 @@
+package cheapjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// the min bytes to read from the reader each time
+const minRead = 512
+
+// Decoder reads JSON values from an io.Reader one by one, the
+// buffer just need to hold one value rather than the whole input,
+// so it could be used for large files and sockets.
+type Decoder struct {
+	r    io.Reader
+	opts *ParseOptions
+	buf  []byte
+	// the start of the unread data in buf
+	scanp int
+	// the bytes dropped from buf
+	scanned int64
+	// the position of buf[scanp]
+	line   int
+	column int
+	// the state to find the end of the next value
+	scanner endScanner
+	// skip a UTF-8 BOM at the start of the input
+	bom bool
+	// the read error, io.EOF if the reader ends
+	err error
+	// the first decode error, the decoder stops at it
+	failed error
+}
+
+// NewDecoder returns a decoder reads from r without any limit.
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, nil)
+}
+
+// NewDecoderWithOptions returns a decoder reads from r, the
+// MaxBytes limit applies to each value rather than the stream.
+func NewDecoderWithOptions(r io.Reader, opts *ParseOptions) *Decoder {
+	bom := opts == nil || !opts.StrictEncoding
+	return &Decoder{r: r, opts: copyable(opts), line: 1, column: 1, bom: bom}
+}
+
+// Decode reads the next value from the input, returns
+// io.EOF if there is no more value.
+func (d *Decoder) Decode() (*Value, error) {
+	if d.failed != nil {
+		return nil, d.failed
+	}
+	end, err := d.readValue()
+	if err != nil {
+		if err != io.EOF {
+			d.failed = err
+		}
+		return nil, err
+	}
+	value, err := unmarshalAt(d.buf[d.scanp:end], d.opts, int(d.InputOffset()))
+	if err != nil {
+		d.relocate(err)
+		d.failed = err
+		return nil, err
+	}
+	d.advance(end)
+	return value, nil
+}
+
+// More reports whether there is another value in the input.
+// It also returns false if the next char is ] or }, so it
+// could be used to walk a sequence of values.
+func (d *Decoder) More() bool {
+	if !d.skip() {
+		return false
+	}
+	c := d.buf[d.scanp]
+	return c != ']' && c != '}'
+}
+
+// InputOffset returns the offset in the input of the end of the
+// last decoded value, the whitespace after it may be included.
+func (d *Decoder) InputOffset() int64 {
+	return d.scanned + int64(d.scanp)
+}
+
+// Buffered returns a reader of the data read from the
+// input but not decoded yet.
+func (d *Decoder) Buffered() io.Reader {
+	return bytes.NewReader(d.buf[d.scanp:])
+}
+
+// readValue buffers the next value, and returns its end in buf.
+func (d *Decoder) readValue() (int, error) {
+	if !d.skip() {
+		if d.err == io.EOF {
+			return 0, io.EOF
+		}
+		return 0, d.err
+	}
+	d.scanner = endScanner{relaxed: d.opts.Relaxed}
+	for {
+		if end := d.scanner.scan(d.buf[d.scanp:]); end >= 0 {
+			return d.scanp + end, nil
+		}
+		if d.err != nil {
+			if d.err == io.EOF {
+				// let the parser report where it ends
+				return len(d.buf), nil
+			}
+			return 0, d.err
+		}
+		if d.opts.MaxBytes > 0 && len(d.buf)-d.scanp > d.opts.MaxBytes {
+			err := exceeded("MaxBytes", d.opts.MaxBytes, d.opts.MaxBytes, d.buf[d.scanp:], "$")
+			d.relocate(err)
+			return 0, err
+		}
+		d.refill()
+	}
+}
+
+// skip drops the leading whitespace, and the comments if
+// Relaxed, reads more if the buffer is drained, returns false
+// if there is no more data.
+func (d *Decoder) skip() bool {
+	if d.bom {
+		d.skipBOM()
+	}
+	for {
+		d.advance(skipWhitespace(d.buf, d.scanp))
+		if d.opts.Relaxed {
+			d.advance(skipComments(d.buf, d.scanp))
+			if d.err != nil && bytes.HasPrefix(d.buf[d.scanp:], bytesLineComment) {
+				// a line comment ends at EOF
+				d.advance(len(d.buf))
+			}
+		}
+		if d.scanp < len(d.buf) && (d.err != nil || !d.opts.Relaxed || !isComment(d.buf[d.scanp:])) {
+			return true
+		}
+		if d.err != nil {
+			return false
+		}
+		d.refill()
+	}
+}
+
+// skipBOM drops the UTF-8 BOM at the start of the input, the
+// UTF-16 and UTF-32 streams are not detected.
+func (d *Decoder) skipBOM() {
+	for len(d.buf) < len(bomUTF8) && bytes.HasPrefix(bomUTF8, d.buf) && d.err == nil {
+		d.refill()
+	}
+	d.bom = false
+	if bytes.HasPrefix(d.buf, bomUTF8) {
+		d.advance(len(bomUTF8))
+	}
+}
+
+// refill reads at least one more byte into buf unless the reader
+// fails, the decoded data is dropped to make the buffer bounded.
+func (d *Decoder) refill() {
+	if d.scanp > 0 {
+		d.scanned += int64(d.scanp)
+		n := copy(d.buf, d.buf[d.scanp:])
+		d.buf = d.buf[:n]
+		d.scanp = 0
+	}
+	if cap(d.buf)-len(d.buf) < minRead {
+		nbuf := make([]byte, len(d.buf), 2*cap(d.buf)+minRead)
+		copy(nbuf, d.buf)
+		d.buf = nbuf
+	}
+	for i := 0; i < 100; i++ {
+		n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+		d.buf = d.buf[:len(d.buf)+n]
+		if err != nil {
+			d.err = err
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+	d.err = io.ErrNoProgress
+}
+
+// advance moves scanp to end, and tracks the position.
+func (d *Decoder) advance(end int) {
+	for _, c := range d.buf[d.scanp:end] {
+		if c == '\n' {
+			d.line++
+			d.column = 1
+		} else if utf8.RuneStart(c) {
+			d.column++
+		}
+	}
+	d.scanp = end
+}
+
+// relocate converts the position of err from the current
+// value to the input.
+func (d *Decoder) relocate(err error) {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		if perr.Line == 1 {
+			perr.Column += d.column - 1
+		}
+		perr.Line += d.line - 1
+		perr.Offset += int(d.InputOffset())
+	}
+}
@@ -0,0 +1,60 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder(t *testing.T) {
+	input := "{\"a\": [1, \"}]\\\"\"]}\n[true]\"str\" 12 -3.5e2\nnull{}"
+	decoder := cheapjson.NewDecoder(iotest.OneByteReader(strings.NewReader(input)))
+	var values []interface{}
+	for decoder.More() {
+		value, err := decoder.Decode()
+		assert.Nil(t, err)
+		values = append(values, value.Value())
+	}
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"a": []interface{}{int64(1), "}]\""}},
+		[]interface{}{true},
+		"str",
+		int64(12),
+		-350.0,
+		nil,
+		map[string]interface{}{},
+	}, values)
+	assert.Equal(t, int64(len(input)), decoder.InputOffset())
+	_, err := decoder.Decode()
+	assert.Equal(t, io.EOF, err)
+
+	decoder = cheapjson.NewDecoder(strings.NewReader("[1]\n [2] {\"a\":\n  tru}"))
+	value, err := decoder.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), decoder.InputOffset())
+	value, err = decoder.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), value.Get("0").Int())
+	_, err = decoder.Decode()
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 17, perr.Offset)
+	assert.Equal(t, 3, perr.Line)
+	assert.Equal(t, 3, perr.Column)
+	assert.Equal(t, "$.a", perr.Path)
+	_, err2 := decoder.Decode()
+	assert.Equal(t, err, err2)
+
+	decoder = cheapjson.NewDecoderWithOptions(strings.NewReader("[1] "+strings.Repeat("[", 4096)), &cheapjson.ParseOptions{MaxBytes: 1024})
+	_, err = decoder.Decode()
+	assert.Nil(t, err)
+	_, err = decoder.Decode()
+	var lerr *cheapjson.LimitError
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, "MaxBytes", lerr.Limit)
+}
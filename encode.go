@@ -0,0 +1,153 @@
+package cheapjson
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+const hex = "0123456789abcdef"
+
+// MarshalJSON returns the compact JSON text of the value, the
+// keys of an object are in the order of Keys. An empty
+// value created by NewValue is encoded as null.
+func (v *Value) MarshalJSON() ([]byte, error) {
+	return appendValue(nil, v)
+}
+
+// AppendJSON appends the compact JSON text of the value to dst.
+func (v *Value) AppendJSON(dst []byte) ([]byte, error) {
+	return appendValue(dst, v)
+}
+
+func appendValue(dst []byte, v *Value) ([]byte, error) {
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	var err error
+	switch value := v.value.(type) {
+	case null, nil:
+		dst = append(dst, "null"...)
+	case bool:
+		if value {
+			dst = append(dst, "true"...)
+		} else {
+			dst = append(dst, "false"...)
+		}
+	case int64:
+		dst = strconv.AppendInt(dst, value, 10)
+	case uint64:
+		dst = strconv.AppendUint(dst, value, 10)
+	case float64:
+		dst, err = appendFloat(dst, value)
+	case number:
+		dst = append(dst, value...)
+	case string:
+		dst = appendString(dst, value)
+	case []*Value:
+		dst = append(dst, '[')
+		for i, elem := range value {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if dst, err = appendValue(dst, elem); err != nil {
+				return dst, err
+			}
+		}
+		dst = append(dst, ']')
+	case map[string]*Value:
+		dst = append(dst, '{')
+		for i, key := range v.Keys() {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendString(dst, key)
+			dst = append(dst, ':')
+			if dst, err = appendValue(dst, value[key]); err != nil {
+				return dst, err
+			}
+		}
+		dst = append(dst, '}')
+	}
+	return dst, err
+}
+
+// appendFloat formats the float like encoding/json.
+func appendFloat(dst []byte, value float64) ([]byte, error) {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return dst, errors.New("unsupported float value: " + strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	abs := math.Abs(value)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, value, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst, nil
+}
+
+// appendString quotes the string, the control chars are escaped,
+// the surrogates in WTF-8 are escaped as \uXXXX, and the other
+// invalid UTF-8 bytes are replaced with U+FFFD.
+func appendString(dst []byte, value string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c >= 0x20 && c != '"' && c != '\\' && c < utf8.RuneSelf {
+			i++
+			continue
+		}
+		if c < utf8.RuneSelf {
+			dst = append(dst, value[start:i]...)
+			switch c {
+			case '"', '\\':
+				dst = append(dst, '\\', c)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case 0x08:
+				dst = append(dst, '\\', 'b')
+			case 0x0C:
+				dst = append(dst, '\\', 'f')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		if c == 0xED && i+2 < len(value) && value[i+1]&0xE0 == 0xA0 && value[i+2]&0xC0 == 0x80 {
+			// a surrogate in WTF-8
+			code := rune(c&0x0F)<<12 | rune(value[i+1]&0x3F)<<6 | rune(value[i+2]&0x3F)
+			dst = append(dst, value[start:i]...)
+			dst = append(dst, '\\', 'u', hex[code>>12], hex[code>>8&0xF], hex[code>>4&0xF], hex[code&0xF])
+			i += 3
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, value[start:i]...)
+			dst = append(dst, '\\', 'u', 'f', 'f', 'f', 'd')
+			i++
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, value[start:]...)
+	return append(dst, '"')
+}
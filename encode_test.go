@@ -0,0 +1,39 @@
+package cheapjson_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	value, err := cheapjson.Unmarshal(normalInput)
+	assert.Nil(t, err)
+	output, err := value.MarshalJSON()
+	assert.Nil(t, err)
+	expected, _ := json.Marshal(value.Value())
+	assert.Equal(t, string(expected), string(output))
+
+	value, err = cheapjson.Unmarshal([]byte(`{"b":[1e-7,1e21,-0.5,"\u0001\"\\<"],"a":null}`))
+	assert.Nil(t, err)
+	output, err = value.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":null,"b":[1e-7,1e+21,-0.5,"\u0001\"\\<"]}`, string(output))
+	output, err = json.Marshal(value)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":null,"b":[1e-7,1e+21,-0.5,"\u0001\"\\\u003c"]}`, string(output))
+
+	value = cheapjson.NewValue()
+	output, err = value.AppendJSON([]byte("x"))
+	assert.Nil(t, err)
+	assert.Equal(t, "xnull", string(output))
+	value.AsString("\xff")
+	output, _ = value.MarshalJSON()
+	assert.Equal(t, `"\ufffd"`, string(output))
+	value.AsFloat(math.NaN())
+	_, err = value.MarshalJSON()
+	assert.NotNil(t, err)
+}
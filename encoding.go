@@ -0,0 +1,190 @@
+package cheapjson
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// encoding is the encoding of an input text.
+type encoding int
+
+const (
+	encodingUTF8 encoding = iota
+	encodingUTF16BE
+	encodingUTF16LE
+	encodingUTF32BE
+	encodingUTF32LE
+)
+
+var (
+	bomUTF8     = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE  = []byte{0xFE, 0xFF}
+	bomUTF16LE  = []byte{0xFF, 0xFE}
+	bomUTF32BE  = []byte{0x00, 0x00, 0xFE, 0xFF}
+	bomUTF32LE  = []byte{0xFF, 0xFE, 0x00, 0x00}
+	expectUTF16 = []string{"valid UTF-16"}
+	expectUTF32 = []string{"valid UTF-32"}
+)
+
+// detectEncoding returns the encoding of data and the size of
+// its BOM. Without a BOM, it is detected by the zero bytes of the
+// first two chars like RFC 4627, which are ASCII in a JSON text.
+func detectEncoding(data []byte) (encoding, int) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return encodingUTF8, len(bomUTF8)
+	case bytes.HasPrefix(data, bomUTF32BE):
+		return encodingUTF32BE, len(bomUTF32BE)
+	case bytes.HasPrefix(data, bomUTF32LE):
+		return encodingUTF32LE, len(bomUTF32LE)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return encodingUTF16BE, len(bomUTF16BE)
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return encodingUTF16LE, len(bomUTF16LE)
+	}
+	if len(data) >= 4 {
+		switch {
+		case data[0] == 0 && data[1] == 0 && data[2] == 0 && data[3] != 0:
+			return encodingUTF32BE, 0
+		case data[0] != 0 && data[1] == 0 && data[2] == 0 && data[3] == 0:
+			return encodingUTF32LE, 0
+		case data[0] == 0 && data[1] != 0 && data[2] == 0 && data[3] != 0:
+			return encodingUTF16BE, 0
+		case data[0] != 0 && data[1] == 0 && data[2] != 0 && data[3] == 0:
+			return encodingUTF16LE, 0
+		}
+	} else if len(data) >= 2 {
+		// a single char, such as 1
+		switch {
+		case data[0] == 0 && data[1] != 0:
+			return encodingUTF16BE, 0
+		case data[0] != 0 && data[1] == 0:
+			return encodingUTF16LE, 0
+		}
+	}
+	return encodingUTF8, 0
+}
+
+// decodeText returns the UTF-8 text of data and the offset of the
+// text after the BOM, unless the StrictEncoding option is set. A
+// UTF-8 text is not copied, and the BOM of a UTF-16 or UTF-32 text
+// is transcoded with it, so the columns count it in all the cases.
+// The invalid units of a UTF-16 or UTF-32 text are replaced with
+// U+FFFD unless InvalidUTF8 is UTF8Reject.
+func decodeText(data []byte, opts *ParseOptions) ([]byte, int, error) {
+	if opts.StrictEncoding {
+		return data, 0, nil
+	}
+	enc, bom := detectEncoding(data)
+	if enc == encodingUTF8 {
+		return data, bom, nil
+	}
+	if bom > 0 {
+		bom = len(bomUTF8)
+	}
+	out, bad := decodeUnits(data, enc, opts.InvalidUTF8 != UTF8Reject)
+	if bad >= 0 {
+		return data, 0, invalidUnit(data, enc, bad)
+	}
+	return out, bom, nil
+}
+
+// decodeUnits converts the UTF-16 or UTF-32 data to UTF-8.
+func decodeUnits(data []byte, enc encoding, replace bool) ([]byte, int) {
+	if enc == encodingUTF16BE || enc == encodingUTF16LE {
+		return decodeUTF16(data, enc == encodingUTF16BE, replace)
+	}
+	return decodeUTF32(data, enc == encodingUTF32BE, replace)
+}
+
+// invalidUnit returns the error of the invalid unit at bad, it is
+// positioned in the transcoded text like the syntax errors, where
+// the unit is replaced with U+FFFD.
+func invalidUnit(data []byte, enc encoding, bad int) error {
+	expect := expectUTF32
+	if enc == encodingUTF16BE || enc == encodingUTF16LE {
+		expect = expectUTF16
+	}
+	// the units before bad are valid and not cut
+	text, _ := decodeUnits(data, enc, true)
+	prefix, _ := decodeUnits(data[:bad], enc, true)
+	return unexpected(expect, len(prefix), text, "$")
+}
+
+// decoded returns the options with StrictEncoding for the texts
+// already decoded, such as the values of a stream whose BOM is
+// skipped at the start, or the children of a LazyValue.
+func decoded(opts *ParseOptions) *ParseOptions {
+	if opts == nil {
+		return &strictOptions
+	}
+	if opts.StrictEncoding {
+		return opts
+	}
+	copied := *opts
+	copied.StrictEncoding = true
+	return &copied
+}
+
+var strictOptions = ParseOptions{StrictEncoding: true}
+
+// decodeUTF16 converts the UTF-16 data to UTF-8, returns the
+// offset of the first invalid unit, or -1.
+func decodeUTF16(data []byte, big bool, replace bool) ([]byte, int) {
+	out := make([]byte, 0, len(data)/2*3/2)
+	unit := func(i int) rune {
+		if big {
+			return rune(data[i])<<8 | rune(data[i+1])
+		}
+		return rune(data[i+1])<<8 | rune(data[i])
+	}
+	for i := 0; i < len(data); i += 2 {
+		if i+1 == len(data) {
+			if !replace {
+				return nil, i
+			}
+			out = utf8.AppendRune(out, utf8.RuneError)
+			break
+		}
+		r := unit(i)
+		if utf16.IsSurrogate(r) {
+			if r < 0xDC00 && i+3 < len(data) {
+				if low := unit(i + 2); low >= 0xDC00 && low <= 0xDFFF {
+					out = utf8.AppendRune(out, utf16.DecodeRune(r, low))
+					i += 2
+					continue
+				}
+			}
+			if !replace {
+				return nil, i
+			}
+			r = utf8.RuneError
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out, -1
+}
+
+// decodeUTF32 converts the UTF-32 data to UTF-8 like decodeUTF16.
+func decodeUTF32(data []byte, big bool, replace bool) ([]byte, int) {
+	out := make([]byte, 0, len(data)/4)
+	for i := 0; i < len(data); i += 4 {
+		var r rune
+		if i+3 >= len(data) {
+			r = -1
+		} else if big {
+			r = rune(data[i])<<24 | rune(data[i+1])<<16 | rune(data[i+2])<<8 | rune(data[i+3])
+		} else {
+			r = rune(data[i+3])<<24 | rune(data[i+2])<<16 | rune(data[i+1])<<8 | rune(data[i])
+		}
+		if !utf8.ValidRune(r) {
+			if !replace {
+				return nil, i
+			}
+			r = utf8.RuneError
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out, -1
+}
@@ -0,0 +1,198 @@
+package cheapjson_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeUTF16(text string, order binary.ByteOrder) []byte {
+	units := utf16.Encode([]rune(text))
+	data := make([]byte, len(units)*2)
+	for i, unit := range units {
+		order.PutUint16(data[i*2:], unit)
+	}
+	return data
+}
+
+func encodeUTF32(text string, order binary.ByteOrder) []byte {
+	runes := []rune(text)
+	data := make([]byte, len(runes)*4)
+	for i, r := range runes {
+		order.PutUint32(data[i*4:], uint32(r))
+	}
+	return data
+}
+
+func TestEncoding(t *testing.T) {
+	text := `{"name": "café ☕", "emoji": "😀", "list": [1, 2.5]}`
+	expected, err := cheapjson.Unmarshal([]byte(text))
+	assert.Nil(t, err)
+	for name, input := range map[string][]byte{
+		"utf8 bom":     append([]byte{0xEF, 0xBB, 0xBF}, text...),
+		"utf16be":      encodeUTF16(text, binary.BigEndian),
+		"utf16le":      encodeUTF16(text, binary.LittleEndian),
+		"utf16be bom":  encodeUTF16("\uFEFF"+text, binary.BigEndian),
+		"utf16le bom":  encodeUTF16("\uFEFF"+text, binary.LittleEndian),
+		"utf32be":      encodeUTF32(text, binary.BigEndian),
+		"utf32le":      encodeUTF32(text, binary.LittleEndian),
+		"utf32be bom":  encodeUTF32("\uFEFF"+text, binary.BigEndian),
+		"utf32le bom":  encodeUTF32("\uFEFF"+text, binary.LittleEndian),
+		"utf16le tiny": encodeUTF16("1", binary.LittleEndian),
+		"utf16be tiny": encodeUTF16("1", binary.BigEndian),
+	} {
+		value, err := cheapjson.Unmarshal(input)
+		assert.Nil(t, err, name)
+		if len(input) <= 4 {
+			assert.Equal(t, int64(1), value.Int(), name)
+			continue
+		}
+		assert.Equal(t, expected.Value(), value.Value(), name)
+		assert.True(t, cheapjson.Valid(input), name)
+
+		lazy, err := cheapjson.Lazy(input)
+		assert.Nil(t, err, name)
+		emoji, err := lazy.Get("emoji")
+		assert.Nil(t, err, name)
+		assert.Equal(t, `"😀"`, string(emoji.Raw()), name)
+
+		_, err = cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{StrictEncoding: true})
+		assert.NotNil(t, err, name)
+	}
+
+	value, err := cheapjson.UnmarshalParallel(encodeUTF16(`["é", 2, {"a": "😀"}]`, binary.LittleEndian), nil, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "😀", value.Get("2", "a").String())
+
+	// the offsets of the UTF-8 BOM text refer to the input
+	input := []byte("\xEF\xBB\xBF[1, x]")
+	_, err = cheapjson.Unmarshal(input)
+	assert.Equal(t, 7, err.(*cheapjson.ParseError).Offset)
+	value, err = cheapjson.UnmarshalWithOptions([]byte("\xEF\xBB\xBF[1]"), &cheapjson.ParseOptions{Spans: true})
+	assert.Nil(t, err)
+	span, _ := value.Span()
+	assert.Equal(t, cheapjson.Span{Start: 3, End: 6}, span)
+
+	// the lines and the columns hold in a UTF-16 text
+	for _, input := range [][]byte{
+		encodeUTF16("[\n1,", binary.LittleEndian),
+		encodeUTF16("\uFEFF[\n1,", binary.BigEndian),
+	} {
+		_, err = cheapjson.Unmarshal(input)
+		perr := err.(*cheapjson.ParseError)
+		assert.Equal(t, 2, perr.Line)
+		assert.Equal(t, 3, perr.Column)
+	}
+
+	// an unpaired surrogate, positioned in the transcoded text
+	input = encodeUTF16("[\n \"ab\"]", binary.LittleEndian)
+	input[8], input[9] = 0x00, 0xD8
+	reject := &cheapjson.ParseOptions{InvalidUTF8: cheapjson.UTF8Reject}
+	_, err = cheapjson.UnmarshalWithOptions(input, reject)
+	perr, ok := err.(*cheapjson.ParseError)
+	assert.True(t, ok)
+	assert.Equal(t, [3]int{4, 2, 3}, [3]int{perr.Offset, perr.Line, perr.Column})
+	assert.Equal(t, '\uFFFD', perr.Char)
+	assert.Equal(t, []string{"valid UTF-16"}, perr.Expected)
+	assert.Equal(t, " \"\uFFFDb\"]\n  ^", perr.Snippet)
+	value, err = cheapjson.Unmarshal(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "\uFFFDb", value.Get("0").String())
+
+	// a truncated unit
+	input = encodeUTF32("\uFEFF[1]", binary.BigEndian)
+	_, err = cheapjson.UnmarshalWithOptions(input[:len(input)-1], reject)
+	perr, ok = err.(*cheapjson.ParseError)
+	assert.True(t, ok)
+	assert.Equal(t, [3]int{5, 1, 4}, [3]int{perr.Offset, perr.Line, perr.Column})
+	assert.Equal(t, []string{"valid UTF-32"}, perr.Expected)
+	assert.NotContains(t, perr.Snippet, "\x00")
+}
+
+// assertPosition checks err is positioned like expected, the
+// snippets of the streams only cover the current value.
+func assertPosition(t *testing.T, expected, err error) {
+	e, ok := expected.(*cheapjson.ParseError)
+	assert.True(t, ok)
+	perr, ok := err.(*cheapjson.ParseError)
+	if assert.True(t, ok, err) {
+		assert.Equal(t, [3]int{e.Offset, e.Line, e.Column}, [3]int{perr.Offset, perr.Line, perr.Column})
+		assert.Equal(t, e.Expected, perr.Expected)
+	}
+}
+
+func TestStreamBOM(t *testing.T) {
+	decoder := cheapjson.NewDecoder(bytes.NewReader([]byte("\xEF\xBB\xBF[1] 2")))
+	value, err := decoder.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), value.Get("0").Int())
+	value, err = decoder.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), value.Int())
+
+	// the reader returns the BOM byte by byte
+	decoder = cheapjson.NewDecoder(iotest.OneByteReader(bytes.NewReader([]byte("\xEF\xBB\xBF1"))))
+	value, err = decoder.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), value.Int())
+
+	reader := cheapjson.NewLineReader(bytes.NewReader([]byte("\xEF\xBB\xBF{\"a\": 1}\n[x]")))
+	value, err = reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), value.Get("a").Int())
+	_, err = reader.Next()
+	assert.Equal(t, 2, err.(*cheapjson.ParseError).Line)
+
+	reader = cheapjson.NewLineReader(bytes.NewReader([]byte("\xEF\xBB\xBF[x]")))
+	_, err = reader.Next()
+	_, expected := cheapjson.Unmarshal([]byte("\xEF\xBB\xBF[x]"))
+	assertPosition(t, expected, err)
+
+	p := cheapjson.NewIncrementalParser(nil)
+	for _, chunk := range []string{"\xEF", "\xBB", "\xBF[", "1]"} {
+		done, err := p.Feed([]byte(chunk))
+		assert.Nil(t, err)
+		assert.Equal(t, chunk == "1]", done)
+	}
+	assert.Equal(t, int64(1), p.Result().Get("0").Int())
+	p.Reset()
+	done, err := p.Feed([]byte("\xEF\xBB\xBF2 "))
+	assert.True(t, done)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), p.Result().Int())
+
+	// a BOM in the middle of a stream is rejected like UnmarshalAll
+	input := []byte("1 \xEF\xBB\xBF2")
+	_, expected = cheapjson.UnmarshalAll(input, nil)
+	assert.NotNil(t, expected)
+	decoder = cheapjson.NewDecoder(bytes.NewReader(input))
+	_, err = decoder.Decode()
+	assert.Nil(t, err)
+	_, err = decoder.Decode()
+	assertPosition(t, expected, err)
+
+	reader = cheapjson.NewLineReader(bytes.NewReader([]byte("1\n\xEF\xBB\xBF2")))
+	_, err = reader.Next()
+	assert.Nil(t, err)
+	_, err = reader.Next()
+	assert.Equal(t, 2, err.(*cheapjson.ParseError).Line)
+	assert.Equal(t, 2, err.(*cheapjson.ParseError).Offset)
+
+	p = cheapjson.NewIncrementalParser(nil)
+	done, err = p.Feed(input)
+	assert.True(t, done)
+	_, err = p.Finish()
+	assertPosition(t, expected, err)
+
+	// the BOM is data with StrictEncoding
+	strict := &cheapjson.ParseOptions{StrictEncoding: true}
+	_, err = cheapjson.NewDecoderWithOptions(bytes.NewReader([]byte("\xEF\xBB\xBF1")), strict).Decode()
+	assert.NotNil(t, err)
+	_, err = cheapjson.NewIncrementalParser(strict).Feed([]byte("\xEF\xBB\xBF1 "))
+	assert.NotNil(t, err)
+}
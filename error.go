@@ -0,0 +1,154 @@
+package cheapjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// the max bytes of a line kept at each side of the
+// error offset in the snippet
+const snippetRadius = 32
+
+// ParseError is returned by Unmarshal when the input is
+// not a valid JSON text. It records where the parser stopped
+// and what it was waiting for, so callers could point at the
+// exact position, or match it with errors.As.
+//
+// For a UTF-16 or UTF-32 input, Offset and Snippet refer to the
+// text transcoded to UTF-8, while Line and Column count the chars,
+// so they hold in the input too.
+type ParseError struct {
+	// the byte offset of the offending token, equals
+	// to the input size if the input ends unexpectedly
+	Offset int
+	// 1-based line and column, the column counts runes
+	Line   int
+	Column int
+	// the offending rune, -1 if the input ends unexpectedly
+	Char rune
+	// the tokens acceptable at Offset
+	Expected []string
+	// the line containing Offset with a caret under it
+	Snippet string
+	// the JSONPath of the value being parsed, such as
+	// $.orders[1532].items[4].price
+	Path string
+	// the cause if the input is rejected for a reason other
+	// than the syntax, such as a *LimitError
+	Err error
+}
+
+// LimitError is wrapped by a *ParseError when the input
+// exceeds a limit of the ParseOptions.
+type LimitError struct {
+	// the name of the ParseOptions field, such as MaxDepth
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit %d exceeded", e.Limit, e.Max)
+}
+
+func (e *ParseError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s at %s (line %d, column %d, offset %d)",
+			e.Err.Error(), e.Path, e.Line, e.Column, e.Offset)
+	}
+	if e.Char < 0 {
+		return fmt.Sprintf("Unexpected EOF at %s (line %d, column %d), expect: %s",
+			e.Path, e.Line, e.Column, strings.Join(e.Expected, ", "))
+	}
+	return fmt.Sprintf("Unexpected token %q at %s (line %d, column %d, offset %d), expect: %s",
+		e.Char, e.Path, e.Line, e.Column, e.Offset, strings.Join(e.Expected, ", "))
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// returns the 1-based line and column of the offset,
+// the column counts runes rather than bytes.
+func position(data []byte, offset int) (line, column int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line = 1
+	start := 0
+	for i := 0; i < offset; i++ {
+		if data[i] == '\n' {
+			line++
+			start = i + 1
+		}
+	}
+	column = utf8.RuneCount(data[start:offset]) + 1
+	return
+}
+
+// returns the line around the offset and a caret line
+// under the offending char, long lines are truncated.
+func snippet(data []byte, offset int) string {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	start := offset
+	for start > 0 && data[start-1] != '\n' && offset-start < snippetRadius {
+		start--
+	}
+	end := offset
+	for end < len(data) && data[end] != '\n' && end-offset < snippetRadius {
+		end++
+	}
+	// do not cut a multi-byte char
+	for start < offset && !utf8.RuneStart(data[start]) {
+		start++
+	}
+	for end < len(data) && end > offset && !utf8.RuneStart(data[end]) {
+		end--
+	}
+	line := strings.TrimRight(string(data[start:end]), "\r")
+	// tabs are kept so the caret keeps aligned in terminals
+	var caret strings.Builder
+	for _, c := range string(data[start:offset]) {
+		if c == '\t' {
+			caret.WriteByte('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+	}
+	caret.WriteByte('^')
+	return line + "\n" + caret.String()
+}
+
+func unexpected(expect []string, offset int, data []byte, path string) error {
+	e := &ParseError{
+		Offset:   offset,
+		Char:     -1,
+		Expected: expect,
+		Path:     path,
+	}
+	if offset < len(data) {
+		e.Char, _ = utf8.DecodeRune(data[offset:])
+	}
+	e.Line, e.Column = position(data, offset)
+	e.Snippet = snippet(data, offset)
+	return e
+}
+
+// DuplicateKeyError is wrapped by a *ParseError when a key appears
+// more than once in an object with the DuplicateError policy.
+type DuplicateKeyError struct {
+	Key string
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return "duplicate key " + strconv.Quote(e.Key)
+}
+
+func exceeded(limit string, max, offset int, data []byte, path string) error {
+	e := unexpected(nil, offset, data, path).(*ParseError)
+	e.Err = &LimitError{limit, max}
+	return e
+}
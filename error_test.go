@@ -0,0 +1,54 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseError(t *testing.T) {
+	_, err := cheapjson.Unmarshal([]byte("{\n  \"hello\": \"world\",\n  \"中文\": tru\n}"))
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 34, perr.Offset)
+	assert.Equal(t, 3, perr.Line)
+	assert.Equal(t, 9, perr.Column)
+	assert.Equal(t, 't', perr.Char)
+	assert.Equal(t, []string{"true"}, perr.Expected)
+	assert.Equal(t, "  \"中文\": tru\n        ^", perr.Snippet)
+	assert.Equal(t, "Unexpected token 't' at $.中文 (line 3, column 9, offset 34), expect: true", err.Error())
+
+	_, err = cheapjson.Unmarshal([]byte("[1, 2"))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 5, perr.Offset)
+	assert.Equal(t, rune(-1), perr.Char)
+	assert.Equal(t, []string{",", "]"}, perr.Expected)
+	assert.Equal(t, "Unexpected EOF at $ (line 1, column 6), expect: ,, ]", err.Error())
+
+	_, err = cheapjson.Unmarshal([]byte("{\"a\" 1}"))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 5, perr.Offset)
+	assert.Equal(t, []string{":"}, perr.Expected)
+
+	_, err = cheapjson.Unmarshal([]byte("1 2"))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, '2', perr.Char)
+	assert.Equal(t, []string{"EOF"}, perr.Expected)
+}
+
+func TestParseErrorPath(t *testing.T) {
+	var perr *cheapjson.ParseError
+	_, err := cheapjson.Unmarshal([]byte(`{"orders":[{},{"items":[1,2,{"price":1.}]}]}`))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "$.orders[1].items[2].price", perr.Path)
+
+	_, err = cheapjson.Unmarshal([]byte(`{"a b":{"c":[1 2]}}`))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, `$["a b"].c`, perr.Path)
+
+	_, err = cheapjson.Unmarshal([]byte(`[{"x":1,}]`))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "$[0]", perr.Path)
+}
@@ -0,0 +1,281 @@
+package cheapjson
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// Handler receives the events of parsing a JSON text, such as
+// to build a domain struct or to compute an aggregate without
+// any intermediate Value. Returning an error aborts the parsing.
+//
+// The strings passed to OnKey and OnString refer to the input or
+// to the buffer of the parser, they are only valid during the call,
+// use strings.Clone to keep them.
+type Handler interface {
+	OnObjectStart() error
+	OnKey(key string) error
+	OnObjectEnd() error
+	OnArrayStart() error
+	OnArrayEnd() error
+	OnString(value string) error
+	OnInt(value int64) error
+	OnFloat(value float64) error
+	OnBool(value bool) error
+	OnNull() error
+}
+
+// NumberHandler is a Handler receives the literal text of the
+// numbers, if the Numbers option is NumberRaw.
+type NumberHandler interface {
+	Handler
+	OnNumber(text string) error
+}
+
+// UintHandler is a Handler receives the positive integers
+// greater than math.MaxInt64 as uint64 rather than applying
+// the Overflow option.
+type UintHandler interface {
+	Handler
+	OnUint(value uint64) error
+}
+
+// handlers is a Handler with its optional interfaces
+type handlers struct {
+	Handler
+	number NumberHandler
+	uint   UintHandler
+	token  tokenHandler
+}
+
+// tokenHandler is a Handler receives the token of each event
+// before the event if the Spans option is set.
+type tokenHandler interface {
+	onToken(tok Token)
+}
+
+// Parse parses a JSON text and sends the events to h.
+func Parse(data []byte, h Handler) error {
+	return ParseWithOptions(data, nil, h)
+}
+
+// ParseWithOptions parses a JSON text with the limits of opts
+// and sends the events to h. The error returned by h is wrapped
+// by a *ParseError positioned at the token.
+func ParseWithOptions(data []byte, opts *ParseOptions, h Handler) error {
+	t := NewTokenizerWithOptions(data, opts)
+	data = t.data
+	if err := drive(t, h); err != nil {
+		return err
+	}
+	if t.offset = skipSpace(data, t.offset, t.opts.Relaxed); t.offset != len(data) {
+		return unexpected(expectEOF, t.offset, data, "$")
+	}
+	return nil
+}
+
+// drive reads the tokens of the next value and sends them to h.
+func drive(t *Tokenizer, h Handler) error {
+	tok, err := t.Next()
+	if err != nil {
+		if err == io.EOF {
+			err = t.unexpected(expectValue, t.offset)
+		}
+		return err
+	}
+	return driveValue(t, h, tok)
+}
+
+// driveValue sends the tokens of the value starts with tok,
+// which is read by the caller.
+func driveValue(t *Tokenizer, h Handler, tok Token) error {
+	hs := newHandlers(h)
+	depth := len(t.stack)
+	if tok.Kind == TokenBeginObject || tok.Kind == TokenBeginArray {
+		depth--
+	}
+	for {
+		if err := hs.send(t, tok); err != nil {
+			return t.abort(err, tok)
+		}
+		if len(t.stack) == depth && tok.Kind != TokenKey {
+			return nil
+		}
+		var err error
+		if tok, err = t.Next(); err != nil {
+			if err == io.EOF {
+				err = t.unexpected(expectValue, t.offset)
+			}
+			return err
+		}
+	}
+}
+
+func newHandlers(h Handler) handlers {
+	hs := handlers{Handler: h}
+	hs.number, _ = h.(NumberHandler)
+	hs.uint, _ = h.(UintHandler)
+	hs.token, _ = h.(tokenHandler)
+	return hs
+}
+
+// send sends the token read by t to the handler.
+func (hs *handlers) send(t *Tokenizer, tok Token) error {
+	if t.opts.Spans && hs.token != nil {
+		hs.token.onToken(tok)
+	}
+	switch tok.Kind {
+	case TokenBeginObject:
+		return hs.OnObjectStart()
+	case TokenEndObject:
+		return hs.OnObjectEnd()
+	case TokenBeginArray:
+		return hs.OnArrayStart()
+	case TokenEndArray:
+		return hs.OnArrayEnd()
+	case TokenKey:
+		return hs.OnKey(unsafeString(tok.Value))
+	case TokenString:
+		return hs.OnString(unsafeString(tok.Value))
+	case TokenNumber:
+		return sendNumber(hs, t.opts, tok)
+	case TokenTrue:
+		return hs.OnBool(true)
+	case TokenFalse:
+		return hs.OnBool(false)
+	case TokenNull:
+		return hs.OnNull()
+	}
+	return nil
+}
+
+// sendNumber converts the number and sends it to h.
+func sendNumber(h *handlers, opts *ParseOptions, tok Token) error {
+	text := unsafeString(tok.Value)
+	if h.number != nil && opts.Numbers == NumberRaw && !tok.relaxed {
+		return h.number.OnNumber(text)
+	}
+	if tok.float && opts.Numbers == NumberIntegral {
+		if value, ok := integral(text); ok {
+			return h.OnInt(value)
+		}
+	}
+	base := 10
+	if tok.relaxed {
+		// the hex integers and the leading +
+		base = 0
+		if strings.HasSuffix(text, "NaN") {
+			// ParseFloat does not accept a signed NaN
+			text = "NaN"
+		}
+	}
+	var err error
+	if tok.float || opts.Numbers == NumberFloat64 && base == 10 {
+		var value float64
+		if value, err = strconv.ParseFloat(text, 64); err == nil {
+			return h.OnFloat(value)
+		}
+	} else {
+		var value int64
+		if value, err = strconv.ParseInt(text, base, 64); err == nil {
+			if opts.Numbers == NumberFloat64 {
+				return h.OnFloat(float64(value))
+			}
+			return h.OnInt(value)
+		}
+		if h.uint != nil && text[0] != '-' {
+			var value uint64
+			if value, err = strconv.ParseUint(strings.TrimPrefix(text, "+"), base, 64); err == nil {
+				if opts.Numbers == NumberFloat64 {
+					return h.OnFloat(float64(value))
+				}
+				return h.uint.OnUint(value)
+			}
+		}
+	}
+	// out of the range
+	switch opts.Overflow {
+	case OverflowFloat:
+		if value, ferr := strconv.ParseFloat(text, 64); ferr == nil {
+			return h.OnFloat(value)
+		}
+	case OverflowRaw:
+		if h.number != nil && !tok.relaxed {
+			return h.number.OnNumber(text)
+		}
+	}
+	return err
+}
+
+// integral returns the value of a number in the JSON syntax
+// if it is an exact integer in the int64 range.
+func integral(text string) (int64, bool) {
+	mantissa, exp := text, 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.Atoi(text[i+1:]); err != nil {
+			// too many digits
+			return 0, false
+		}
+		mantissa = text[:i]
+	}
+	digits := mantissa
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits = mantissa[:i] + mantissa[i+1:]
+		exp -= len(mantissa) - i - 1
+	}
+	sign := ""
+	switch digits[0] {
+	case '-':
+		sign, digits = "-", digits[1:]
+	case '+':
+		// JSON5
+		digits = digits[1:]
+	}
+	digits = strings.TrimLeft(digits, "0")
+	for exp < 0 && len(digits) > 0 && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+		exp++
+	}
+	if len(digits) == 0 {
+		return 0, true
+	}
+	// 19 digits at most for int64
+	if exp < 0 || len(digits)+exp > 19 {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(sign+digits+strings.Repeat("0", exp), 10, 64)
+	return value, err == nil
+}
+
+// abort stops the tokenizer with the error of a handler
+// at the token.
+func (t *Tokenizer) abort(err error, tok Token) error {
+	// point at the scalar rather than its container
+	t.inValue = tok.Kind >= TokenString
+	perr := unexpected(nil, tok.Offset, t.data, t.Path()).(*ParseError)
+	perr.Err = err
+	t.err = perr
+	return perr
+}
+
+// refers reports if the string refers to the bytes of data.
+func refers(data []byte, s string) bool {
+	if len(data) == 0 || len(s) == 0 {
+		return false
+	}
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(data)))
+	p := uintptr(unsafe.Pointer(unsafe.StringData(s)))
+	return p >= start && p+uintptr(len(s)) <= start+uintptr(len(data))
+}
+
+// unsafeString returns a string refers to the bytes without copy,
+// the bytes must not be modified while the string is in use.
+func unsafeString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(&b[0], len(b))
+}
@@ -0,0 +1,75 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+// sums the prices and records the events
+type sumHandler struct {
+	events []string
+	key    string
+	sum    float64
+	limit  int
+}
+
+func (h *sumHandler) event(e string) error {
+	h.events = append(h.events, e)
+	if h.limit > 0 && len(h.events) >= h.limit {
+		return errors.New("too many events")
+	}
+	return nil
+}
+
+func (h *sumHandler) OnObjectStart() error { return h.event("{") }
+func (h *sumHandler) OnKey(key string) error {
+	h.key = strings.Clone(key)
+	return h.event(h.key + ":")
+}
+func (h *sumHandler) OnObjectEnd() error  { return h.event("}") }
+func (h *sumHandler) OnArrayStart() error { return h.event("[") }
+func (h *sumHandler) OnArrayEnd() error   { return h.event("]") }
+func (h *sumHandler) OnString(value string) error {
+	return h.event(strings.Clone(value))
+}
+func (h *sumHandler) OnInt(value int64) error {
+	if h.key == "price" {
+		h.sum += float64(value)
+	}
+	return h.event("int")
+}
+func (h *sumHandler) OnFloat(value float64) error {
+	if h.key == "price" {
+		h.sum += value
+	}
+	return h.event("float")
+}
+func (h *sumHandler) OnBool(value bool) error { return h.event("bool") }
+func (h *sumHandler) OnNull() error           { return h.event("null") }
+
+func TestParse(t *testing.T) {
+	input := []byte(`[{"price": 1, "name": "a\"b"}, {"price": 2.5, "ok": true, "x": null}]`)
+	h := &sumHandler{}
+	assert.Nil(t, cheapjson.Parse(input, h))
+	assert.Equal(t, 3.5, h.sum)
+	assert.Equal(t, []string{
+		"[", "{", "price:", "int", "name:", "a\"b", "}",
+		"{", "price:", "float", "ok:", "bool", "x:", "null", "}", "]",
+	}, h.events)
+
+	h = &sumHandler{limit: 4}
+	err := cheapjson.Parse(input, h)
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "too many events", perr.Err.Error())
+	assert.Equal(t, 11, perr.Offset)
+	assert.Equal(t, "$[0].price", perr.Path)
+
+	err = cheapjson.Parse([]byte(`[1] 2`), &sumHandler{})
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, []string{"EOF"}, perr.Expected)
+}
@@ -0,0 +1,297 @@
+package cheapjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// IncrementalParser parses JSON values fed in chunks of any size,
+// such as the fragments of the messages of a socket. The tokens
+// complete in the fed data are built at once, and the state stack
+// is kept between the chunks, so a value is not parsed again when
+// more data arrives. It is not safe for concurrent use.
+//
+//	for chunk := range chunks {
+//		done, err := p.Feed(chunk)
+//		for done && err == nil {
+//			handle(p.Result())
+//			done, err = p.Feed(nil)
+//		}
+//	}
+type IncrementalParser struct {
+	opts *ParseOptions
+	t    Tokenizer
+	b    valueBuilder
+	hs   handlers
+	// the data of the current value, and the data after it
+	buf []byte
+	// the bytes dropped from buf
+	scanned int64
+	// the position of buf[0]
+	line   int
+	column int
+	// the last token is cut by the end of buf, it is read again
+	// after scanner finds the end of it since buf[from]
+	waiting bool
+	from    int
+	scanner endScanner
+	value   *Value
+	done    bool
+	// the first error, the parser stops at it
+	err error
+	// the StrictEncoding option, which is always set in opts for
+	// the BOM is only skipped at the start of the stream
+	strict bool
+	bom    bool
+}
+
+// NewIncrementalParser returns a parser with the options, the
+// MaxBytes limit applies to each value rather than the stream.
+func NewIncrementalParser(opts *ParseOptions) *IncrementalParser {
+	p := &IncrementalParser{opts: copyable(opts), strict: opts != nil && opts.StrictEncoding}
+	p.Reset()
+	return p
+}
+
+// Feed appends the chunk to the input and parses it, returns true
+// if a value is completed, which is returned by Result. The data
+// after the value is kept, and the next call starts the next value,
+// so a nil chunk could be fed to parse the rest. A number at the end
+// of the input is not completed until more data is fed or Finish is
+// called, since it may continue in the next chunk.
+func (p *IncrementalParser) Feed(chunk []byte) (bool, error) {
+	if p.err != nil {
+		return false, p.err
+	}
+	if p.done {
+		p.next()
+	}
+	p.buf = append(p.buf, chunk...)
+	if p.bom {
+		if len(p.buf) < len(bomUTF8) && bytes.HasPrefix(bomUTF8, p.buf) {
+			// the BOM may be cut
+			return false, nil
+		}
+		p.skipBOM()
+	}
+	if p.waiting {
+		if p.scanner.scan(p.buf[p.from:]) < 0 {
+			return false, p.limit(len(p.buf))
+		}
+		p.waiting = false
+	}
+	return p.parse(false)
+}
+
+// Finish reports the end of the input, it completes the value
+// ends at the end of the input, such as a number, or returns an
+// error if the input ends in a value. It returns false without
+// an error if there is only whitespace left.
+func (p *IncrementalParser) Finish() (bool, error) {
+	if p.err != nil {
+		return false, p.err
+	}
+	if p.done {
+		p.next()
+	}
+	if p.bom {
+		p.skipBOM()
+	}
+	p.waiting = false
+	return p.parse(true)
+}
+
+// Result returns the value completed by the last Feed or Finish,
+// or nil if there is none.
+func (p *IncrementalParser) Result() *Value {
+	if !p.done {
+		return nil
+	}
+	return p.value
+}
+
+// Reset drops the input and the state, so the parser could be
+// reused for another stream.
+func (p *IncrementalParser) Reset() {
+	*p = IncrementalParser{
+		opts:   p.opts,
+		buf:    p.buf[:0],
+		line:   1,
+		column: 1,
+		strict: p.strict,
+		bom:    !p.strict,
+	}
+	p.t.reset(nil, p.opts)
+	p.b.reset(&p.t, nil)
+	p.hs = newHandlers(&p.b)
+}
+
+// skipBOM drops the UTF-8 BOM at the start of the input, the
+// UTF-16 and UTF-32 streams are not detected.
+func (p *IncrementalParser) skipBOM() {
+	p.bom = false
+	if bytes.HasPrefix(p.buf, bomUTF8) {
+		p.drop(len(bomUTF8))
+		p.b.base = int(p.scanned)
+	}
+}
+
+// next drops the last value, and starts the next one.
+func (p *IncrementalParser) next() {
+	p.drop(p.t.offset)
+	p.value = nil
+	p.done = false
+	p.t.reset(nil, p.opts)
+	p.b.reset(&p.t, nil)
+	p.b.base = int(p.scanned)
+}
+
+// drop drops the data before end, and tracks the position.
+func (p *IncrementalParser) drop(end int) {
+	for _, c := range p.buf[:end] {
+		if c == '\n' {
+			p.line++
+			p.column = 1
+		} else if utf8.RuneStart(c) {
+			p.column++
+		}
+	}
+	p.scanned += int64(end)
+	n := copy(p.buf, p.buf[end:])
+	p.buf = p.buf[:n]
+}
+
+// parse reads the tokens of buf, and stops before the last token
+// if it may be cut by the end of buf, unless final.
+func (p *IncrementalParser) parse(final bool) (bool, error) {
+	t := &p.t
+	t.data = p.buf
+	p.b.data = p.buf
+	for {
+		m := t.mark()
+		tok, err := t.Next()
+		if err == io.EOF {
+			// only whitespace
+			t.restore(m)
+			return false, p.limit(len(p.buf))
+		}
+		if err != nil {
+			var perr *ParseError
+			if !final && errors.As(err, &perr) && perr.Err == nil && p.wait(p.start(m.offset)) {
+				t.restore(m)
+				return false, p.limit(len(p.buf))
+			}
+			return false, p.fail(err)
+		}
+		if !final && tok.End == len(p.buf) && cut(tok, p.buf) && p.wait(tok.Offset) {
+			t.restore(m)
+			return false, p.limit(len(p.buf))
+		}
+		if err = p.hs.send(t, tok); err != nil {
+			return false, p.fail(t.abort(err, tok))
+		}
+		if len(t.stack) == 0 && tok.Kind != TokenKey {
+			if err = p.limit(t.offset); err != nil {
+				return false, err
+			}
+			p.value = p.b.root
+			p.done = true
+			return true, nil
+		}
+	}
+}
+
+// wait reports if the token since from may continue in the next
+// chunk, and waits for the end of it.
+func (p *IncrementalParser) wait(from int) bool {
+	p.scanner = endScanner{relaxed: p.opts.Relaxed}
+	p.from = from
+	p.waiting = p.scanner.scan(p.buf[from:]) < 0
+	return p.waiting
+}
+
+// start returns the start of the token read after offset, the
+// comma or colon before it is skipped.
+func (p *IncrementalParser) start(offset int) int {
+	offset = p.space(offset)
+	if offset < len(p.buf) && (p.buf[offset] == ',' || p.buf[offset] == ':') {
+		offset = p.space(offset + 1)
+	}
+	return offset
+}
+
+// space skips the whitespace and the complete comments.
+func (p *IncrementalParser) space(offset int) int {
+	offset = skipWhitespace(p.buf, offset)
+	if p.opts.Relaxed {
+		offset = skipComments(p.buf, offset)
+	}
+	return offset
+}
+
+// cut reports if the token may continue after the end of data.
+func cut(tok Token, data []byte) bool {
+	switch tok.Kind {
+	case TokenNumber, TokenTrue, TokenFalse, TokenNull:
+		return true
+	case TokenKey:
+		// an unquoted key of JSON5
+		return data[tok.Offset] != '"' && data[tok.Offset] != '\''
+	}
+	return false
+}
+
+// limit checks the MaxBytes limit with the bytes of the value.
+func (p *IncrementalParser) limit(n int) error {
+	if p.opts.MaxBytes > 0 && n > p.opts.MaxBytes {
+		return p.fail(exceeded("MaxBytes", p.opts.MaxBytes, p.opts.MaxBytes, p.buf, "$"))
+	}
+	return nil
+}
+
+// fail stops the parser with the error positioned in the input.
+func (p *IncrementalParser) fail(err error) error {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		if perr.Line == 1 {
+			perr.Column += p.column - 1
+		}
+		perr.Line += p.line - 1
+		perr.Offset += int(p.scanned)
+	}
+	p.err = err
+	return err
+}
+
+// mark is the state of a tokenizer before a token.
+type mark struct {
+	offset  int
+	depth   int
+	top     frame
+	nodes   int
+	inValue bool
+}
+
+// mark returns the state to restore if the next token is
+// cut by the end of the data. A token changes the top frame
+// or pushes a frame only.
+func (t *Tokenizer) mark() mark {
+	m := mark{offset: t.offset, depth: len(t.stack), nodes: t.nodes, inValue: t.inValue}
+	if m.depth > 0 {
+		m.top = t.stack[m.depth-1]
+	}
+	return m
+}
+
+func (t *Tokenizer) restore(m mark) {
+	t.offset = m.offset
+	t.stack = t.stack[:m.depth]
+	if m.depth > 0 {
+		t.stack[m.depth-1] = m.top
+	}
+	t.nodes = m.nodes
+	t.inValue = m.inValue
+	t.err = nil
+}
@@ -0,0 +1,127 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrementalParser(t *testing.T) {
+	for _, c := range []struct {
+		input string
+		opts  *cheapjson.ParseOptions
+	}{
+		{`{"aé😀b": [true, false, null, -12.5e+3, "x\"y", {}, []], "c": 12}`, nil},
+		{"[\"é\U0001F600\", 1234567890]", nil},
+		{"{abc: [-Infinity, Infinity, 0x1F, 'a\\x41', 1., .5,], /* c */ d // e\n: 1}", relaxed},
+		{`"string"`, nil},
+	} {
+		expected, err := cheapjson.UnmarshalWithOptions([]byte(c.input), c.opts)
+		assert.Nil(t, err)
+		// every split of the input into two chunks, and byte by byte
+		for i := 0; i < len(c.input); i++ {
+			p := cheapjson.NewIncrementalParser(c.opts)
+			done, err := p.Feed([]byte(c.input[:i]))
+			assert.Nil(t, err, c.input[:i])
+			assert.False(t, done, c.input[:i])
+			done, err = p.Feed([]byte(c.input[i:]))
+			assert.Nil(t, err, c.input)
+			if assert.True(t, done, c.input, i) {
+				assert.Equal(t, expected.Value(), p.Result().Value())
+			}
+		}
+		p := cheapjson.NewIncrementalParser(c.opts)
+		done := false
+		for i := 0; i < len(c.input); i++ {
+			done, err = p.Feed([]byte{c.input[i]})
+			assert.Nil(t, err)
+			assert.Equal(t, i == len(c.input)-1, done, c.input[:i+1])
+		}
+		assert.Equal(t, expected.Value(), p.Result().Value())
+	}
+
+	expected, err := cheapjson.Unmarshal(normalInput)
+	assert.Nil(t, err)
+	for _, size := range []int{3, 7, 64} {
+		p := cheapjson.NewIncrementalParser(nil)
+		done := false
+		for i := 0; i < len(normalInput); i += size {
+			done, err = p.Feed(normalInput[i:min(i+size, len(normalInput))])
+			assert.Nil(t, err)
+		}
+		if assert.True(t, done) {
+			assert.Equal(t, expected.Value(), p.Result().Value())
+		}
+	}
+
+	// a stream of values
+	p := cheapjson.NewIncrementalParser(nil)
+	var values []interface{}
+	for _, chunk := range []string{`{"a": 1} [2`, `] 3`, ``, ` "4"  `} {
+		done, err := p.Feed([]byte(chunk))
+		for done && err == nil {
+			values = append(values, p.Result().Value())
+			done, err = p.Feed(nil)
+		}
+		assert.Nil(t, err)
+	}
+	done, err := p.Finish()
+	assert.False(t, done)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"a": int64(1)}, []interface{}{int64(2)}, int64(3), "4"}, values)
+
+	// a number is completed by Finish
+	p.Reset()
+	done, err = p.Feed([]byte(`12`))
+	assert.False(t, done)
+	assert.Nil(t, err)
+	assert.Nil(t, p.Result())
+	done, err = p.Finish()
+	assert.True(t, done)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), p.Result().Int())
+
+	// the errors are positioned in the stream
+	p.Reset()
+	done, err = p.Feed([]byte("[1]\n[2, "))
+	assert.True(t, done)
+	done, err = p.Feed([]byte("tru"))
+	assert.False(t, done)
+	assert.Nil(t, err)
+	// the error is reported at the end of the token
+	done, err = p.Feed([]byte("e x"))
+	assert.False(t, done)
+	assert.Nil(t, err)
+	_, err = p.Feed([]byte("]"))
+	var perr *cheapjson.ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, 13, perr.Offset)
+		assert.Equal(t, 2, perr.Line)
+		assert.Equal(t, 10, perr.Column)
+		assert.Equal(t, "$", perr.Path)
+	}
+	_, err = p.Feed([]byte("]"))
+	assert.Equal(t, perr, err)
+
+	p.Reset()
+	_, err = p.Feed([]byte(`["a\q"`))
+	assert.NotNil(t, err)
+	p.Reset()
+	_, err = p.Feed([]byte(`{"a": [1`))
+	assert.Nil(t, err)
+	_, err = p.Finish()
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 8, perr.Offset)
+
+	p = cheapjson.NewIncrementalParser(&cheapjson.ParseOptions{MaxBytes: 8})
+	done, err = p.Feed([]byte(`[1] [2] [3`))
+	for done && err == nil {
+		done, err = p.Feed(nil)
+	}
+	assert.Nil(t, err)
+	_, err = p.Feed([]byte(`, 4, 5, 6]`))
+	var lerr *cheapjson.LimitError
+	assert.True(t, errors.As(err, &lerr))
+}
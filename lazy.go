@@ -0,0 +1,170 @@
+package cheapjson
+
+import (
+	"io"
+	"strconv"
+)
+
+// LazyValue is a JSON value which is only decoded on demand, such
+// as to read a few fields of a large document. Get decodes the
+// containers along the path, and skips the other values by
+// matching the brackets, so the skipped data is only checked for
+// the brackets and the quotes.
+type LazyValue struct {
+	// the text ends at the end of the value
+	data  []byte
+	start int
+	opts  *ParseOptions
+	// the children indexed by Get
+	fields map[string]*LazyValue
+	elems  []*LazyValue
+}
+
+// Lazy scans the structure of a JSON text without decoding it.
+func Lazy(data []byte) (*LazyValue, error) {
+	return LazyWithOptions(data, nil)
+}
+
+// LazyWithOptions scans the structure of a JSON text without
+// decoding it, the options apply to the values decoded later.
+func LazyWithOptions(data []byte, opts *ParseOptions) (*LazyValue, error) {
+	t := NewTokenizerWithOptions(data, opts)
+	data = t.data
+	tok, err := t.Next()
+	if err == nil {
+		tok.End, err = t.skipValue(tok)
+	} else if err == io.EOF {
+		err = t.unexpected(expectValue, t.offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if end := skipSpace(data, tok.End, t.opts.Relaxed); end != len(data) {
+		return nil, unexpected(expectEOF, end, data, "$")
+	}
+	// the children are decoded already
+	return &LazyValue{data: data[:tok.End], start: tok.Offset, opts: decoded(t.opts)}, nil
+}
+
+// Raw returns the text of the value, it refers to the input.
+func (v *LazyValue) Raw() []byte {
+	return v.data[v.start:]
+}
+
+// Value decodes the value, the errors are positioned in the
+// input of Lazy.
+func (v *LazyValue) Value() (*Value, error) {
+	return build(v.tokenizer())
+}
+
+// Get returns the value at the path like Value.Get, or nil if
+// the path does not exist. The DuplicateKeys option decides
+// which value of a duplicated key is returned. The children of
+// the containers along the path are indexed on the first Get,
+// so a LazyValue is not safe for concurrent use.
+func (v *LazyValue) Get(path ...string) (*LazyValue, error) {
+	value := v
+	for _, key := range path {
+		if err := value.index(); err != nil {
+			return nil, err
+		}
+		if value.fields != nil {
+			value = value.fields[key]
+		} else if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(value.elems) {
+			value = value.elems[index]
+		} else {
+			value = nil
+		}
+		if value == nil {
+			return nil, nil
+		}
+	}
+	return value, nil
+}
+
+// index records the children of an object or array once,
+// a scalar is not indexed.
+func (v *LazyValue) index() error {
+	if v.fields != nil || v.elems != nil {
+		return nil
+	}
+	t := v.tokenizer()
+	tok, err := t.Next()
+	if err != nil {
+		return err
+	}
+	switch tok.Kind {
+	case TokenBeginObject:
+		fields, err := v.indexObject(t)
+		if err != nil {
+			return err
+		}
+		v.fields = fields
+	case TokenBeginArray:
+		elems, err := v.indexArray(t)
+		if err != nil {
+			return err
+		}
+		v.elems = elems
+	}
+	return nil
+}
+
+func (v *LazyValue) indexObject(t *Tokenizer) (map[string]*LazyValue, error) {
+	fields := map[string]*LazyValue{}
+	for {
+		tok, err := t.Next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Kind == TokenEndObject {
+			return fields, nil
+		}
+		key := string(tok.Value)
+		if tok, err = t.Next(); err != nil {
+			return nil, err
+		}
+		end, err := t.skipValue(tok)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := fields[key]; ok {
+			switch t.opts.DuplicateKeys {
+			case DuplicateFirstWins:
+				continue
+			case DuplicateError:
+				return nil, t.abort(&DuplicateKeyError{key}, tok)
+			}
+		}
+		fields[key] = v.child(tok.Offset, end)
+	}
+}
+
+func (v *LazyValue) indexArray(t *Tokenizer) ([]*LazyValue, error) {
+	elems := []*LazyValue{}
+	for {
+		tok, err := t.Next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Kind == TokenEndArray {
+			return elems, nil
+		}
+		end, err := t.skipValue(tok)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, v.child(tok.Offset, end))
+	}
+}
+
+func (v *LazyValue) child(start, end int) *LazyValue {
+	return &LazyValue{data: v.data[:end], start: start, opts: v.opts}
+}
+
+// tokenizer returns a tokenizer reads the value.
+func (v *LazyValue) tokenizer() *Tokenizer {
+	t := NewTokenizerWithOptions(v.data, v.opts)
+	t.offset = v.start
+	return t
+}
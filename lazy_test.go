@@ -0,0 +1,76 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazy(t *testing.T) {
+	input := []byte(` {"skip": {"a": [1, "}"]}, "list": [10, {"x": "y\n"}, [true]], "dup": 1, "dup": 2} `)
+	lazy, err := cheapjson.Lazy(input)
+	assert.Nil(t, err)
+	assert.Equal(t, input[1:len(input)-1], lazy.Raw())
+
+	value, err := lazy.Get("list", "1", "x")
+	assert.Nil(t, err)
+	assert.Equal(t, `"y\n"`, string(value.Raw()))
+	decoded, err := value.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "y\n", decoded.String())
+
+	value, err = lazy.Get("list", "2")
+	assert.Nil(t, err)
+	decoded, err = value.Value()
+	assert.Nil(t, err)
+	assert.True(t, decoded.Get("0").IsTrue())
+
+	value, err = lazy.Get("dup")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", string(value.Raw()))
+	lazy, err = cheapjson.LazyWithOptions(input, &cheapjson.ParseOptions{DuplicateKeys: cheapjson.DuplicateFirstWins})
+	assert.Nil(t, err)
+	value, err = lazy.Get("dup")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", string(value.Raw()))
+
+	for _, path := range [][]string{{"none"}, {"list", "3"}, {"list", "-1"}, {"list", "a"}, {"list", "0", "x"}} {
+		value, err = lazy.Get(path...)
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+	}
+
+	_, err = cheapjson.Lazy([]byte(`{"a": [1, 2}`))
+	assert.NotNil(t, err)
+	_, err = cheapjson.Lazy([]byte(`{} []`))
+	assert.NotNil(t, err)
+
+	// the skipped values are not checked
+	lazy, err = cheapjson.Lazy([]byte(`{"bad": [nul], "ok": 1, "next": {"a" 1}}`))
+	assert.Nil(t, err)
+	value, err = lazy.Get("ok")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", string(value.Raw()))
+	_, err = lazy.Get("next", "a")
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 37, perr.Offset)
+}
+
+func BenchmarkLazyGetBigInput(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		lazy, err := cheapjson.Lazy(bigInput)
+		if err != nil {
+			b.Fatal(err)
+		}
+		keys := 0
+		for _, key := range []string{"1", "2", "3"} {
+			if value, _ := lazy.Get(key); value != nil {
+				keys++
+			}
+		}
+		_ = keys
+	}
+}
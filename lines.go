@@ -0,0 +1,168 @@
+package cheapjson
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// LineReader reads newline delimited JSON (NDJSON, JSON Lines),
+// each non blank line must hold exactly one value. A malformed
+// line does not stop the reader, so the rest of a log file could
+// still be read.
+type LineReader struct {
+	r    *bufio.Reader
+	opts *ParseOptions
+	// the current line, 1-based
+	line int
+	// the offset of the current line in the input
+	offset int64
+	// the next line starts at
+	next int64
+	// the buffer for a line longer than the bufio buffer
+	buf []byte
+	err error
+	// skip a UTF-8 BOM at the start of the input
+	bom bool
+}
+
+// NewLineReader returns a reader reads lines from r
+// without any limit.
+func NewLineReader(r io.Reader) *LineReader {
+	return NewLineReaderWithOptions(r, nil)
+}
+
+// NewLineReaderWithOptions returns a reader reads lines
+// from r, the limits apply to each line.
+func NewLineReaderWithOptions(r io.Reader, opts *ParseOptions) *LineReader {
+	bom := opts == nil || !opts.StrictEncoding
+	return &LineReader{r: bufio.NewReader(r), opts: copyable(opts), bom: bom}
+}
+
+// Next returns the value of the next non blank line, or io.EOF
+// if there is no more line. If the line is malformed, returns a
+// *ParseError positioned in the input, and the next call
+// continues from the next line.
+func (r *LineReader) Next() (*Value, error) {
+	for {
+		data, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		// the BOM is a char of the first line
+		column := 0
+		if r.bom {
+			r.bom = false
+			if bytes.HasPrefix(data, bomUTF8) {
+				data = data[len(bomUTF8):]
+				r.offset += int64(len(bomUTF8))
+				column = 1
+			}
+		}
+		if skipSpace(data, 0, r.opts.Relaxed) == len(data) {
+			continue
+		}
+		value, err := unmarshalAt(data, r.opts, int(r.offset))
+		if err != nil {
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				if perr.Line == 1 {
+					perr.Column += column
+				}
+				perr.Line += r.line - 1
+				perr.Offset += int(r.offset)
+			}
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// Line returns the line number of the last value or error.
+func (r *LineReader) Line() int {
+	return r.line
+}
+
+// readLine returns the next line without the line feed, the
+// returned slice is only valid until the next call.
+func (r *LineReader) readLine() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.line++
+	r.offset = r.next
+	r.buf = r.buf[:0]
+	for {
+		data, err := r.r.ReadSlice('\n')
+		r.next += int64(len(data))
+		if err == bufio.ErrBufferFull {
+			r.buf = append(r.buf, data...)
+			if r.opts.MaxBytes > 0 && len(r.buf) > r.opts.MaxBytes {
+				return r.skipLine()
+			}
+			continue
+		}
+		if len(r.buf) > 0 {
+			data = append(r.buf, data...)
+			r.buf = data
+		}
+		if err != nil {
+			if err != io.EOF || len(data) == 0 {
+				r.err = err
+				return nil, err
+			}
+			// the last line without a line feed
+			r.err = io.EOF
+		}
+		if n := len(data); n > 0 && data[n-1] == '\n' {
+			data = data[:n-1]
+		}
+		return data, nil
+	}
+}
+
+// skipLine drops the rest of a line exceeds MaxBytes,
+// and returns the limit error.
+func (r *LineReader) skipLine() ([]byte, error) {
+	data := r.buf
+	for {
+		line, err := r.r.ReadSlice('\n')
+		r.next += int64(len(line))
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			r.err = err
+		}
+		break
+	}
+	perr := exceeded("MaxBytes", r.opts.MaxBytes, r.opts.MaxBytes, data, "$").(*ParseError)
+	perr.Line = r.line
+	perr.Offset += int(r.offset)
+	return nil, perr
+}
+
+// LineWriter writes values as newline delimited JSON, each
+// value is written in the compact form followed by a line feed.
+type LineWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewLineWriter returns a writer writes lines to w.
+func NewLineWriter(w io.Writer) *LineWriter {
+	return &LineWriter{w: w}
+}
+
+// Write writes the value as a line.
+func (w *LineWriter) Write(value *Value) error {
+	var err error
+	w.buf, err = appendValue(w.buf[:0], value)
+	if err != nil {
+		return err
+	}
+	w.buf = append(w.buf, '\n')
+	_, err = w.w.Write(w.buf)
+	return err
+}
@@ -0,0 +1,83 @@
+package cheapjson_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalAll(t *testing.T) {
+	values, err := cheapjson.UnmarshalAll([]byte(" {\"a\":1}[2]\n\"3\" 4 "), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(values))
+	assert.Equal(t, int64(1), values[0].Get("a").Int())
+	assert.Equal(t, "3", values[2].String())
+	values, err = cheapjson.UnmarshalAll([]byte("1 2 [3,]"), nil)
+	assert.Equal(t, 2, len(values))
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 7, perr.Offset)
+	values, err = cheapjson.UnmarshalAll([]byte(" \n"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(values))
+
+	// the adjacent scalars are rejected like the Decoder
+	for _, input := range []string{"1-23", "123t", "2 truefalse", "1.5\"a\"x"} {
+		values, err = cheapjson.UnmarshalAll([]byte(input), nil)
+		decoder := cheapjson.NewDecoder(strings.NewReader(input))
+		var expected error
+		for expected == nil {
+			_, expected = decoder.Decode()
+		}
+		assertPosition(t, expected, err)
+	}
+	values, err = cheapjson.UnmarshalAll([]byte("1\"a\"[2]{}\"b\"3"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(values))
+}
+
+func TestLineReader(t *testing.T) {
+	input := "{\"a\":1}\n\n  [1, 2\n\"ok\"\r\n" + strings.Repeat("1", 5000) + "\nnull"
+	reader := cheapjson.NewLineReaderWithOptions(strings.NewReader(input), &cheapjson.ParseOptions{MaxBytes: 4096})
+	value, err := reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), value.Get("a").Int())
+	assert.Equal(t, 1, reader.Line())
+	_, err = reader.Next()
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 3, reader.Line())
+	assert.Equal(t, 3, perr.Line)
+	assert.Equal(t, 8, perr.Column)
+	assert.Equal(t, 16, perr.Offset)
+	value, err = reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", value.String())
+	_, err = reader.Next()
+	var lerr *cheapjson.LimitError
+	assert.True(t, errors.As(err, &lerr))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 5, perr.Line)
+	value, err = reader.Next()
+	assert.Nil(t, err)
+	assert.True(t, value.IsNull())
+	assert.Equal(t, 6, reader.Line())
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLineWriter(t *testing.T) {
+	values, err := cheapjson.UnmarshalAll([]byte("{\"a\": [1, \"x\\ny\"]}\n  true\n"), nil)
+	assert.Nil(t, err)
+	var out bytes.Buffer
+	writer := cheapjson.NewLineWriter(&out)
+	for _, value := range values {
+		assert.Nil(t, writer.Write(value))
+	}
+	assert.Equal(t, "{\"a\":[1,\"x\\ny\"]}\ntrue\n", out.String())
+}
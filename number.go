@@ -0,0 +1,127 @@
+package cheapjson
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// the literal text of a number parsed with NumberRaw
+type number string
+
+// the max exponent of a raw number BigInt accepts
+const maxBigExp = 4096
+
+// AsNumber sets the value as a raw number, the text must be
+// a valid JSON number, and it is encoded as is.
+func (v *Value) AsNumber(text string) {
+	v.value = number(text)
+}
+
+// IsRawNumber reports whether the value keeps the literal
+// text of a number.
+func (v *Value) IsRawNumber() bool {
+	_, ok := v.value.(number)
+	return ok
+}
+
+// Number returns the literal text of a raw number, or the
+// formatted text of an int or float.
+func (v *Value) Number() string {
+	switch value := v.value.(type) {
+	case number:
+		return string(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	case float64:
+		out, _ := appendFloat(nil, value)
+		return string(out)
+	}
+	panic("not a number value")
+}
+
+// BigInt returns the number as a big.Int if it is an integer,
+// such as 12345678901234567890 or 1.5e3.
+func (v *Value) BigInt() (*big.Int, bool) {
+	switch value := v.value.(type) {
+	case int64:
+		return big.NewInt(value), true
+	case uint64:
+		return new(big.Int).SetUint64(value), true
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) || value != math.Trunc(value) {
+			return nil, false
+		}
+		out, _ := big.NewFloat(value).Int(nil)
+		return out, true
+	case number:
+		if out, ok := new(big.Int).SetString(string(value), 10); ok {
+			return out, true
+		}
+		// has a fraction or an exponent, the exponent is limited
+		// as a hostile one such as 1e999999999 costs a lot
+		if i := strings.IndexAny(string(value), "eE"); i >= 0 {
+			if exp, err := strconv.Atoi(string(value[i+1:])); err != nil || exp > maxBigExp || exp < -maxBigExp {
+				return nil, false
+			}
+		}
+		rat, ok := new(big.Rat).SetString(string(value))
+		if !ok || !rat.IsInt() {
+			return nil, false
+		}
+		return rat.Num(), true
+	}
+	return nil, false
+}
+
+// BigFloat returns the number as a big.Float, the precision
+// of a raw number is enough to keep all its digits.
+func (v *Value) BigFloat() (*big.Float, bool) {
+	switch value := v.value.(type) {
+	case int64:
+		return new(big.Float).SetInt64(value), true
+	case uint64:
+		return new(big.Float).SetUint64(value), true
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return nil, false
+		}
+		return big.NewFloat(value), true
+	case number:
+		prec := uint(len(value))*4 + 64
+		out, _, err := big.ParseFloat(string(value), 10, prec, big.ToNearestEven)
+		if err != nil {
+			return nil, false
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// Uint64 returns the number as a uint64 if it is an
+// integer in the uint64 range.
+func (v *Value) Uint64() (uint64, bool) {
+	switch value := v.value.(type) {
+	case int64:
+		if value >= 0 {
+			return uint64(value), true
+		}
+	case uint64:
+		return value, true
+	case float64:
+		if value >= 0 && value < (1<<64) && value == math.Trunc(value) {
+			return uint64(value), true
+		}
+	case number:
+		if out, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			return out, true
+		}
+		if out, ok := v.BigInt(); ok && out.IsUint64() {
+			return out.Uint64(), true
+		}
+	}
+	return 0, false
+}
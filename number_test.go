@@ -0,0 +1,152 @@
+package cheapjson_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawNumber(t *testing.T) {
+	input := []byte(`{"id":12345678901234567890,"amount":0.10000000000000000000001,"n":-12,"e":1.5E3,"big":1e999999999}`)
+	_, err := cheapjson.Unmarshal(input)
+	assert.NotNil(t, err)
+
+	value, err := cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{Numbers: cheapjson.NumberRaw})
+	assert.Nil(t, err)
+	id := value.Get("id")
+	assert.True(t, id.IsRawNumber())
+	assert.True(t, id.IsNumber())
+	assert.False(t, id.IsInt())
+	assert.Equal(t, "12345678901234567890", id.Number())
+	bigInt, ok := id.BigInt()
+	assert.True(t, ok)
+	assert.Equal(t, "12345678901234567890", bigInt.String())
+	u, ok := id.Uint64()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(12345678901234567890), u)
+
+	amount := value.Get("amount")
+	_, ok = amount.BigInt()
+	assert.False(t, ok)
+	bigFloat, ok := amount.BigFloat()
+	assert.True(t, ok)
+	assert.Equal(t, "0.10000000000000000000001", bigFloat.Text('f', 23))
+	assert.Equal(t, 0.1, amount.Float())
+
+	n := value.Get("n")
+	assert.True(t, n.IsInt())
+	assert.Equal(t, int64(-12), n.Int())
+	_, ok = n.Uint64()
+	assert.False(t, ok)
+
+	bigInt, ok = value.Get("e").BigInt()
+	assert.True(t, ok)
+	assert.Equal(t, int64(1500), bigInt.Int64())
+	u, ok = value.Get("e").Uint64()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1500), u)
+	_, ok = value.Get("big").BigInt()
+	assert.False(t, ok)
+
+	output, err := value.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"amount":0.10000000000000000000001,"big":1e999999999,"e":1.5E3,"id":12345678901234567890,"n":-12}`, string(output))
+	output, err = json.Marshal(value.Get("id").Value())
+	assert.Nil(t, err)
+	assert.Equal(t, "12345678901234567890", string(output))
+
+	value = cheapjson.NewValue()
+	value.AsFloat(2.5)
+	assert.Equal(t, "2.5", value.Number())
+	bigFloat, _ = value.BigFloat()
+	assert.Equal(t, 0, bigFloat.Cmp(big.NewFloat(2.5)))
+	value.AsNumber("7")
+	assert.Equal(t, int64(7), value.Int())
+}
+
+func TestUintAndOverflow(t *testing.T) {
+	input := []byte(`[9223372036854775807,9223372036854775808,18446744073709551615,18446744073709551616,-9223372036854775809]`)
+	_, err := cheapjson.Unmarshal(input)
+	var perr *cheapjson.ParseError
+	var nerr *strconv.NumError
+	assert.True(t, errors.As(err, &perr))
+	assert.True(t, errors.As(err, &nerr))
+	assert.Equal(t, 62, perr.Offset)
+	assert.Equal(t, "$[3]", perr.Path)
+
+	value, err := cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{Overflow: cheapjson.OverflowFloat})
+	assert.Nil(t, err)
+	assert.True(t, value.Get("0").IsInt())
+	assert.True(t, value.Get("1").IsUint())
+	assert.False(t, value.Get("1").IsInt())
+	assert.True(t, value.Get("1").IsNumber())
+	assert.Equal(t, uint64(9223372036854775808), value.Get("1").Uint())
+	assert.Equal(t, uint64(math.MaxUint64), value.Get("2").Uint())
+	assert.Equal(t, 1.8446744073709552e19, value.Get("3").Float())
+	assert.False(t, value.Get("3").IsUint())
+	assert.Equal(t, -9.223372036854776e18, value.Get("4").Float())
+	output, err := value.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `[9223372036854775807,9223372036854775808,18446744073709551615,18446744073709552000,-9223372036854776000]`, string(output))
+
+	value, err = cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{Overflow: cheapjson.OverflowRaw})
+	assert.Nil(t, err)
+	assert.True(t, value.Get("1").IsUint())
+	assert.True(t, value.Get("3").IsRawNumber())
+	assert.Equal(t, "-9223372036854775809", value.Get("4").Number())
+	output, err = value.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(input), string(output))
+
+	_, err = cheapjson.UnmarshalWithOptions([]byte(`1e400`), &cheapjson.ParseOptions{Overflow: cheapjson.OverflowFloat})
+	assert.True(t, errors.As(err, &nerr))
+
+	value = cheapjson.NewValue()
+	value.AsUint(3)
+	assert.Equal(t, 3.0, value.Float())
+	assert.Equal(t, "3", value.Number())
+	u, ok := value.Uint64()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), u)
+}
+
+func TestNumberModes(t *testing.T) {
+	input := []byte(`[1e3,1.0,2E+2,-1.50e1,1.5,1e21,9223372036854775807.0,-9223372036854775808e0,0.0e-5,12300e-2,123e-2,1e-400,7]`)
+	value, err := cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{Numbers: cheapjson.NumberIntegral})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), value.Get("0").Int())
+	assert.Equal(t, int64(1), value.Get("1").Int())
+	assert.Equal(t, int64(200), value.Get("2").Int())
+	assert.Equal(t, int64(-15), value.Get("3").Int())
+	assert.False(t, value.Get("4").IsInt())
+	assert.Equal(t, 1.5, value.Get("4").Float())
+	assert.False(t, value.Get("5").IsInt())
+	assert.Equal(t, 1e21, value.Get("5").Float())
+	assert.Equal(t, int64(math.MaxInt64), value.Get("6").Int())
+	assert.Equal(t, int64(math.MinInt64), value.Get("7").Int())
+	assert.Equal(t, int64(0), value.Get("8").Int())
+	assert.Equal(t, int64(123), value.Get("9").Int())
+	assert.False(t, value.Get("10").IsInt())
+	assert.False(t, value.Get("11").IsInt())
+	assert.Equal(t, int64(7), value.Get("12").Int())
+
+	value, err = cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{Numbers: cheapjson.NumberFloat64})
+	assert.Nil(t, err)
+	for _, elem := range value.Array() {
+		assert.IsType(t, 0.0, elem.Value())
+	}
+	assert.Equal(t, 7.0, value.Get("12").Float())
+	output, err := value.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `[1000,1,200,-15,1.5,1e+21,9223372036854776000,-9223372036854776000,0,123,1.23,0,7]`, string(output))
+
+	value, err = cheapjson.UnmarshalWithOptions([]byte(`18446744073709551616`), &cheapjson.ParseOptions{Numbers: cheapjson.NumberFloat64})
+	assert.Nil(t, err)
+	assert.Equal(t, 1.8446744073709552e19, value.Float())
+}
@@ -0,0 +1,142 @@
+package cheapjson
+
+// ParseOptions controls the behavior of UnmarshalWithOptions,
+// the zero value keeps the behavior of Unmarshal.
+type ParseOptions struct {
+	// the max nesting level of objects and arrays
+	MaxDepth int
+	// the max bytes of a decoded string or key
+	MaxStringLen int
+	// the max members of an object
+	MaxKeys int
+	// the max elements of an array
+	MaxElements int
+	// the max count of the values in the document,
+	// includes the containers
+	MaxNodes int
+	// the max bytes of the input
+	MaxBytes int
+	// what to do if a key appears more than once in an object
+	DuplicateKeys DuplicateKeyPolicy
+	// build the objects keep the order of the keys in the input
+	PreserveOrder bool
+	// how to convert the numbers
+	Numbers NumberMode
+	// what to do if an integer is out of the int64 and uint64
+	// range, or a float is out of the float64 range
+	Overflow OverflowPolicy
+	// accept the JSON5 syntax for the hand-written files: the //
+	// and /* */ comments, the trailing commas, the single quoted
+	// strings, the unquoted keys, the hex numbers, the leading +,
+	// the leading or trailing decimal point, Infinity and NaN.
+	// A number out of the JSON syntax is never kept as the raw
+	// text, and Infinity and NaN could not be encoded.
+	Relaxed bool
+	// what to do with the invalid UTF-8 bytes in the strings
+	InvalidUTF8 UTF8Policy
+	// what to do with a \u escape of an unpaired UTF-16 surrogate
+	Surrogates SurrogatePolicy
+	// the strings, keys and raw numbers without any escape refer
+	// to the input rather than being copied, so the input must not
+	// be modified while the Value is in use. It is ignored by the
+	// Decoder and the LineReader, which reuse their buffers.
+	ZeroCopy bool
+	// record the span of each value in the input, see Value.Span
+	Spans bool
+	// do not skip a UTF-8 BOM or transcode a UTF-16 or UTF-32
+	// text, which are detected by default
+	StrictEncoding bool
+}
+
+// SurrogatePolicy decides what to do with an unpaired surrogate,
+// such as \ud800 not followed by a low surrogate, or \udc00 not
+// preceded by a high one.
+type SurrogatePolicy int
+
+const (
+	// abort with a *ParseError at the escape
+	SurrogateError SurrogatePolicy = iota
+	// replace the surrogate with U+FFFD
+	SurrogateReplace
+	// encode the surrogate as is like WTF-8, the string is not
+	// valid UTF-8, but it is encoded back to the same \u escape
+	SurrogateWTF8
+)
+
+// UTF8Policy decides what to do with the invalid UTF-8 bytes
+// in the strings and keys.
+type UTF8Policy int
+
+const (
+	// keep the bytes as is, the strings may be invalid UTF-8
+	UTF8Pass UTF8Policy = iota
+	// abort with a *ParseError at the first invalid byte
+	UTF8Reject
+	// replace each invalid byte with U+FFFD, like encoding/json
+	UTF8Replace
+)
+
+// OverflowPolicy decides what to do with a number out of range.
+type OverflowPolicy int
+
+const (
+	// abort with a *ParseError wrapping the *strconv.NumError
+	OverflowError OverflowPolicy = iota
+	// convert an integer to the nearest float64, a number
+	// out of the float64 range is still an error
+	OverflowFloat
+	// keep the literal text like NumberRaw
+	OverflowRaw
+)
+
+// NumberMode decides how to convert the numbers.
+type NumberMode int
+
+const (
+	// an integer is an int64, and the others are float64,
+	// a number out of the range is an error
+	NumberDefault NumberMode = iota
+	// keep the literal text of the numbers like json.Number, it
+	// could be got by Value.Number and is encoded as is, the
+	// Handler receives it by OnNumber if it is a NumberHandler
+	NumberRaw
+	// a number is an int64 if its value is an exact integer in
+	// the int64 range, such as 1e3, 1.0 and 2E+2, the others
+	// are the same as NumberDefault
+	NumberIntegral
+	// all the numbers are float64, like encoding/json decodes
+	// into an interface{}
+	NumberFloat64
+)
+
+// DuplicateKeyPolicy decides what to do if a key appears
+// more than once in an object.
+type DuplicateKeyPolicy int
+
+const (
+	// the last value overwrites the previous ones
+	DuplicateLastWins DuplicateKeyPolicy = iota
+	// the first value is kept, the others are dropped
+	DuplicateFirstWins
+	// abort with a *ParseError wrapping a *DuplicateKeyError
+	// at the second occurrence
+	DuplicateError
+	// the last value is kept as the field, and all the values
+	// could be got by Value.Duplicates
+	DuplicateCollect
+)
+
+var defaultOptions = ParseOptions{}
+
+// copyable returns the options without ZeroCopy for the readers
+// reuse the buffer of the input, and with StrictEncoding for the
+// BOM is only skipped at the start of the stream.
+func copyable(opts *ParseOptions) *ParseOptions {
+	opts = decoded(opts)
+	if opts.ZeroCopy {
+		copied := *opts
+		copied.ZeroCopy = false
+		return &copied
+	}
+	return opts
+}
@@ -0,0 +1,204 @@
+package cheapjson_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	limit := func(input string, opts *cheapjson.ParseOptions) (*cheapjson.ParseError, *cheapjson.LimitError) {
+		_, err := cheapjson.UnmarshalWithOptions([]byte(input), opts)
+		var perr *cheapjson.ParseError
+		var lerr *cheapjson.LimitError
+		assert.True(t, errors.As(err, &perr), input)
+		assert.True(t, errors.As(err, &lerr), input)
+		return perr, lerr
+	}
+	perr, lerr := limit(`{"a":[[1]]}`, &cheapjson.ParseOptions{MaxDepth: 2})
+	assert.Equal(t, "MaxDepth", lerr.Limit)
+	assert.Equal(t, 2, lerr.Max)
+	assert.Equal(t, 6, perr.Offset)
+	assert.Equal(t, "$.a[0]", perr.Path)
+	assert.Equal(t, "MaxDepth limit 2 exceeded at $.a[0] (line 1, column 7, offset 6)", perr.Error())
+	_, lerr = limit(`["abc", "abcd"]`, &cheapjson.ParseOptions{MaxStringLen: 3})
+	assert.Equal(t, "MaxStringLen", lerr.Limit)
+	_, lerr = limit(`{"a":1,"b":2,"c":3}`, &cheapjson.ParseOptions{MaxKeys: 2})
+	assert.Equal(t, "MaxKeys", lerr.Limit)
+	perr, lerr = limit(`[1,2,3]`, &cheapjson.ParseOptions{MaxElements: 2})
+	assert.Equal(t, "MaxElements", lerr.Limit)
+	assert.Equal(t, "$[2]", perr.Path)
+	perr, _ = limit(`{"a":[[],[]]}`, &cheapjson.ParseOptions{MaxElements: 1})
+	assert.Equal(t, "$.a[1]", perr.Path)
+	assert.Equal(t, 9, perr.Offset)
+	_, lerr = limit(`[1,[2,3]]`, &cheapjson.ParseOptions{MaxNodes: 4})
+	assert.Equal(t, "MaxNodes", lerr.Limit)
+	_, lerr = limit(`[1,2,3]`, &cheapjson.ParseOptions{MaxBytes: 6})
+	assert.Equal(t, "MaxBytes", lerr.Limit)
+
+	perr, _ = limit(string(bytes.Repeat([]byte{'['}, 10<<20)), &cheapjson.ParseOptions{MaxDepth: 64})
+	assert.Equal(t, 64, perr.Offset)
+
+	value, err := cheapjson.UnmarshalWithOptions([]byte(`{"a":[1,"abc"],"b":{}}`), &cheapjson.ParseOptions{
+		MaxDepth:     2,
+		MaxStringLen: 3,
+		MaxKeys:      2,
+		MaxElements:  2,
+		MaxNodes:     5,
+		MaxBytes:     22,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", value.Get("a", "1").String())
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	input := []byte(`{"role":"user","x":{"a":1},"role":{"is":"admin"},"role":3}`)
+	value, err := cheapjson.Unmarshal(input)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), value.Get("role").Int())
+	assert.Nil(t, value.Duplicates("role"))
+
+	value, err = cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{DuplicateKeys: cheapjson.DuplicateFirstWins})
+	assert.Nil(t, err)
+	assert.Equal(t, "user", value.Get("role").String())
+	assert.Equal(t, 2, len(value.Object()))
+
+	value, err = cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{DuplicateKeys: cheapjson.DuplicateCollect})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), value.Get("role").Int())
+	roles := value.Duplicates("role")
+	assert.Equal(t, 3, len(roles))
+	assert.Equal(t, "user", roles[0].String())
+	assert.Equal(t, "admin", roles[1].Get("is").String())
+	assert.Equal(t, roles[2], value.Get("role"))
+	assert.Nil(t, value.Duplicates("x"))
+
+	_, err = cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{DuplicateKeys: cheapjson.DuplicateError})
+	var perr *cheapjson.ParseError
+	var derr *cheapjson.DuplicateKeyError
+	assert.True(t, errors.As(err, &perr))
+	assert.True(t, errors.As(err, &derr))
+	assert.Equal(t, "role", derr.Key)
+	assert.Equal(t, 27, perr.Offset)
+	assert.Equal(t, "$.role", perr.Path)
+	assert.Equal(t, `duplicate key "role" at $.role (line 1, column 28, offset 27)`, err.Error())
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	input := []byte("{\"k\xff\": [\"ok 中文\", \"a\xc3(\", \"\\n\xed\xa0\x80\"]}")
+	reject := &cheapjson.ParseOptions{InvalidUTF8: cheapjson.UTF8Reject}
+	_, err := cheapjson.UnmarshalWithOptions(input, reject)
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 3, perr.Offset)
+	assert.Equal(t, []string{"valid UTF-8"}, perr.Expected)
+	_, err = cheapjson.UnmarshalWithOptions(input[7:], reject)
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 16, perr.Offset)
+	assert.Equal(t, "$[1]", perr.Path)
+
+	value, err := cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{InvalidUTF8: cheapjson.UTF8Replace})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"k\uFFFD"}, value.Keys())
+	assert.Equal(t, "ok 中文", value.Get("k\uFFFD", "0").String())
+	assert.Equal(t, "a\uFFFD(", value.Get("k\uFFFD", "1").String())
+	assert.Equal(t, "\n\uFFFD\uFFFD\uFFFD", value.Get("k\uFFFD", "2").String())
+
+	// the bytes are passed by default
+	value, err = cheapjson.Unmarshal(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "a\xc3(", value.Get("k\xff", "1").String())
+
+	value, err = cheapjson.UnmarshalWithOptions([]byte("'\xff\\x41'"), &cheapjson.ParseOptions{Relaxed: true, InvalidUTF8: cheapjson.UTF8Replace})
+	assert.Nil(t, err)
+	assert.Equal(t, "\uFFFDA", value.String())
+	_, err = cheapjson.UnmarshalWithOptions([]byte("'\\x41\xff'"), &cheapjson.ParseOptions{Relaxed: true, InvalidUTF8: cheapjson.UTF8Reject})
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 5, perr.Offset)
+}
+
+func TestSurrogates(t *testing.T) {
+	input := []byte(`["😂", "a\udc00b", "\ud800", "\ud800A"]`)
+	_, err := cheapjson.Unmarshal(input)
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 13, perr.Offset)
+	assert.Equal(t, "$[1]", perr.Path)
+	_, err = cheapjson.Unmarshal([]byte(`"\ud800"`))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 7, perr.Offset)
+	assert.Equal(t, []string{"\\"}, perr.Expected)
+
+	value, err := cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{Surrogates: cheapjson.SurrogateReplace})
+	assert.Nil(t, err)
+	assert.Equal(t, "😂", value.Get("0").String())
+	assert.Equal(t, "a\uFFFDb", value.Get("1").String())
+	assert.Equal(t, "\uFFFD", value.Get("2").String())
+	assert.Equal(t, "\uFFFDA", value.Get("3").String())
+
+	value, err = cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{Surrogates: cheapjson.SurrogateWTF8})
+	assert.Nil(t, err)
+	assert.Equal(t, "a\xed\xb0\x80b", value.Get("1").String())
+	output, err := value.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `["😂","a\udc00b","\ud800","\ud800A"]`, string(output))
+
+	// the unpaired surrogates are not positioned as errors
+	input = []byte(`"` + strings.Repeat(`\ud800`, 10000) + `"`)
+	replace := &cheapjson.ParseOptions{Surrogates: cheapjson.SurrogateReplace}
+	value, err = cheapjson.UnmarshalWithOptions(input, replace)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("\uFFFD", 10000), value.String())
+	allocs := testing.AllocsPerRun(5, func() {
+		_, _ = cheapjson.UnmarshalWithOptions(input, replace)
+	})
+	// the buffers may grow again, but there is no error per surrogate
+	assert.Less(t, allocs, 100.0)
+}
+
+// refers reports if the string refers to the bytes of data.
+func refers(data []byte, s string) bool {
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(data)))
+	p := uintptr(unsafe.Pointer(unsafe.StringData(s)))
+	return p >= start && p < start+uintptr(len(data))
+}
+
+func TestZeroCopy(t *testing.T) {
+	input := []byte(`["plain", "esc\"aped", 1e2, {"key": 1}]`)
+	opts := &cheapjson.ParseOptions{ZeroCopy: true, Numbers: cheapjson.NumberRaw}
+	value, err := cheapjson.UnmarshalWithOptions(input, opts)
+	assert.Nil(t, err)
+	elems := value.Array()
+	assert.Equal(t, "plain", elems[0].String())
+	assert.True(t, refers(input, elems[0].String()))
+	assert.Equal(t, "esc\"aped", elems[1].String())
+	assert.False(t, refers(input, elems[1].String()))
+	assert.Equal(t, "1e2", elems[2].Number())
+	assert.True(t, refers(input, elems[2].Number()))
+	value, err = cheapjson.Unmarshal(input)
+	assert.Nil(t, err)
+	assert.False(t, refers(input, value.Get("0").String()))
+
+	input = bytes.Repeat([]byte(`"abcdefgh",`), 100)
+	input = append(append([]byte{'['}, input[:len(input)-1]...), ']')
+	copied := testing.AllocsPerRun(10, func() {
+		_, _ = cheapjson.Unmarshal(input)
+	})
+	viewed := testing.AllocsPerRun(10, func() {
+		_, _ = cheapjson.UnmarshalWithOptions(input, opts)
+	})
+	assert.Less(t, viewed, copied-90)
+
+	input = []byte(`"plain" "next"`)
+	decoder := cheapjson.NewDecoderWithOptions(bytes.NewReader(input), opts)
+	value, err = decoder.Decode()
+	assert.Nil(t, err)
+	// the decoder reuses its buffer, so the strings are copied
+	assert.Equal(t, "plain", value.String())
+	assert.False(t, refers(input, value.String()))
+}
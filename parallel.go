@@ -0,0 +1,314 @@
+package cheapjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+)
+
+var expectArray = []string{"["}
+
+// chunkSize is the least bytes of the values of a task, so the
+// small values are not sent to the workers one by one.
+const chunkSize = 64 << 10
+
+// UnmarshalParallel parses a JSON text whose top level value is
+// an array, the elements are parsed concurrently by the workers,
+// or by runtime.GOMAXPROCS(0) goroutines if workers is not
+// positive. MaxBytes and MaxElements apply to the whole text, and
+// the other limits apply to each element. It is faster than
+// UnmarshalWithOptions only for a large array.
+func UnmarshalParallel(data []byte, opts *ParseOptions, workers int) (*Value, error) {
+	var values []*Value
+	err := RangeParallel(data, opts, workers, func(index int, value *Value) error {
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	value := NewValue()
+	value.AsArray(values)
+	return value, nil
+}
+
+// RangeParallel parses the elements of the top level array of data
+// concurrently like UnmarshalParallel, and calls fn for each element
+// in the order of the array. It stops at the first error, which
+// is either a *ParseError or returned by fn, the elements before
+// the error are passed to fn.
+func RangeParallel(data []byte, opts *ParseOptions, workers int, fn func(index int, value *Value) error) error {
+	p := parallel{data: data, opts: opts}
+	return p.run(workers, fn)
+}
+
+// RangeLinesParallel parses newline delimited JSON concurrently
+// like RangeParallel, the index passed to fn counts the values
+// rather than the lines, the blank lines are skipped. The limits
+// apply to each line.
+func RangeLinesParallel(data []byte, opts *ParseOptions, workers int, fn func(index int, value *Value) error) error {
+	p := parallel{data: data, opts: opts, lines: true}
+	return p.run(workers, fn)
+}
+
+// span is the range of a value in the input
+type span struct {
+	start int
+	end   int
+	// the line of a NDJSON value, 1-based
+	line int
+}
+
+// chunk is a task of the workers.
+type chunk struct {
+	// the index of the first value
+	index  int
+	spans  []span
+	values []*Value
+	// the error of the values, or of the splitting if spans is empty
+	err error
+	// closed if the values are parsed
+	done chan struct{}
+}
+
+// parallel splits the values of the input and parses them by the
+// workers, the chunks are sent to ordered in order, so the values
+// could be collected in order.
+type parallel struct {
+	data []byte
+	// the size of the BOM at the start of data
+	bom     int
+	opts    *ParseOptions
+	lines   bool
+	tasks   chan *chunk
+	ordered chan *chunk
+	// closed if the collecting stops
+	stop chan struct{}
+}
+
+func (p *parallel) run(workers int, fn func(index int, value *Value) error) error {
+	if p.opts == nil {
+		p.opts = &defaultOptions
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// transcode once rather than in each chunk
+	data, bom, err := decodeText(p.data, p.opts)
+	if err != nil {
+		return err
+	}
+	p.data, p.bom, p.opts = data, bom, decoded(p.opts)
+	p.tasks = make(chan *chunk, workers)
+	p.ordered = make(chan *chunk, workers*2)
+	p.stop = make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(workers + 1)
+	go func() {
+		defer wg.Done()
+		p.split()
+	}()
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			p.work()
+		}()
+	}
+	err = p.collect(fn)
+	close(p.stop)
+	// do not return before the workers stop using the input
+	wg.Wait()
+	return err
+}
+
+func (p *parallel) collect(fn func(index int, value *Value) error) error {
+	for c := range p.ordered {
+		<-c.done
+		for i, value := range c.values {
+			if err := fn(c.index+i, value); err != nil {
+				return err
+			}
+		}
+		if c.err != nil {
+			return c.err
+		}
+	}
+	return nil
+}
+
+// split sends the chunks of the values, and the error of the
+// syntax out of the values at last.
+func (p *parallel) split() {
+	defer close(p.ordered)
+	defer close(p.tasks)
+	c := &chunk{done: make(chan struct{})}
+	start := 0
+	emit := func(s span) bool {
+		if len(c.spans) == 0 {
+			start = s.start
+		}
+		c.spans = append(c.spans, s)
+		if s.end-start < chunkSize {
+			return true
+		}
+		next := &chunk{index: c.index + len(c.spans), done: make(chan struct{})}
+		ok := p.send(c)
+		c = next
+		return ok
+	}
+	var err error
+	if p.lines {
+		err = p.splitLines(emit)
+	} else {
+		err = p.splitArray(emit)
+	}
+	if len(c.spans) > 0 && !p.send(c) {
+		return
+	}
+	if err != nil {
+		c = &chunk{err: err, done: make(chan struct{})}
+		close(c.done)
+		select {
+		case p.ordered <- c:
+		case <-p.stop:
+		}
+	}
+}
+
+// send sends the chunk to the collector and the workers, returns
+// false if the collecting stops.
+func (p *parallel) send(c *chunk) bool {
+	select {
+	case p.ordered <- c:
+	case <-p.stop:
+		return false
+	}
+	select {
+	case p.tasks <- c:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
+
+// splitArray emits the elements of the top level array, and checks
+// the syntax out of the elements.
+func (p *parallel) splitArray(emit func(s span) bool) error {
+	t := NewTokenizerWithOptions(p.data, p.opts)
+	t.offset = p.bom
+	tok, err := t.Next()
+	if err == io.EOF {
+		err = t.unexpected(expectValue, t.offset)
+	}
+	if err != nil {
+		return err
+	}
+	if tok.Kind != TokenBeginArray {
+		return unexpected(expectArray, tok.Offset, p.data, "$")
+	}
+	for {
+		tok, err = t.Next()
+		if err != nil {
+			return err
+		}
+		if tok.Kind == TokenEndArray {
+			break
+		}
+		end, err := t.skipValue(tok)
+		if err != nil {
+			return err
+		}
+		if !emit(span{start: tok.Offset, end: end}) {
+			return nil
+		}
+	}
+	if end := skipSpace(p.data, t.offset, p.opts.Relaxed); end != len(p.data) {
+		return unexpected(expectEOF, end, p.data, "$")
+	}
+	return nil
+}
+
+// splitLines emits the non blank lines.
+func (p *parallel) splitLines(emit func(s span) bool) error {
+	data := p.data
+	for offset, line := p.bom, 1; offset < len(data); line++ {
+		end := len(data)
+		next := end
+		if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+			end = offset + i
+			next = end + 1
+		}
+		if skipSpace(data[:end], offset, p.opts.Relaxed) != end {
+			if !emit(span{start: offset, end: end, line: line}) {
+				return nil
+			}
+		}
+		offset = next
+	}
+	return nil
+}
+
+func (p *parallel) work() {
+	var t Tokenizer
+	var b valueBuilder
+	for c := range p.tasks {
+		select {
+		case <-p.stop:
+			close(c.done)
+			continue
+		default:
+		}
+		for i, s := range c.spans {
+			var value *Value
+			var err error
+			if p.lines {
+				value, err = p.parseLine(&t, &b, s)
+			} else {
+				value, err = p.parseElement(&t, &b, c.index+i, s)
+			}
+			if err != nil {
+				c.err = err
+				break
+			}
+			c.values = append(c.values, value)
+		}
+		close(c.done)
+	}
+}
+
+// parseElement parses the element at the index of the top level
+// array, the error is positioned in the array.
+func (p *parallel) parseElement(t *Tokenizer, b *valueBuilder, index int, s span) (*Value, error) {
+	t.reset(p.data, p.opts)
+	// read the element as in the array
+	t.stack = append(t.stack, frame{state: stateArrayEndOrComma, index: index})
+	t.offset = s.start
+	tok, err := t.readValue()
+	if err == nil {
+		b.reset(t, nil)
+		err = driveValue(t, b, tok)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.root, nil
+}
+
+// parseLine parses the line like LineReader.Next.
+func (p *parallel) parseLine(t *Tokenizer, b *valueBuilder, s span) (*Value, error) {
+	t.reset(p.data[s.start:s.end], p.opts)
+	b.reset(t, nil)
+	b.base = s.start
+	value, err := parse(t, b)
+	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			perr.Line += s.line - 1
+			perr.Offset += s.start
+		}
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,126 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalParallel(t *testing.T) {
+	var records []string
+	for i := 0; i < 10000; i++ {
+		records = append(records, fmt.Sprintf(`{"id": %d, "name": "record %d", "tags": ["a", "b"]}`, i, i))
+	}
+	input := []byte("[" + strings.Join(records, ",\n") + ", 1, \"s\"]")
+	expected, err := cheapjson.Unmarshal(input)
+	assert.Nil(t, err)
+	for _, workers := range []int{0, 1, 3} {
+		value, err := cheapjson.UnmarshalParallel(input, nil, workers)
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Value(), value.Value())
+	}
+
+	next := 0
+	err = cheapjson.RangeParallel(input, nil, 4, func(index int, value *cheapjson.Value) error {
+		assert.Equal(t, next, index)
+		next++
+		if index == 5000 {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	assert.Equal(t, "stop", err.Error())
+	assert.Equal(t, 5001, next)
+
+	value, err := cheapjson.UnmarshalParallel([]byte(` [] `), nil, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(value.Array()))
+
+	for _, c := range []struct {
+		input  string
+		offset int
+		path   string
+	}{
+		{`[1, {"a": [tru]}]`, 11, "$[1].a[0]"},
+		{`[1, {"a": 1 "b": 2}]`, 12, "$[1]"},
+		{`[1, 2`, 5, "$"},
+		{`[1] 2`, 4, "$"},
+		{`{"a": 1}`, 0, "$"},
+		{``, 0, "$"},
+	} {
+		_, err = cheapjson.UnmarshalParallel([]byte(c.input), nil, 2)
+		var perr *cheapjson.ParseError
+		if assert.True(t, errors.As(err, &perr), c.input) {
+			assert.Equal(t, c.offset, perr.Offset, c.input)
+			assert.Equal(t, c.path, perr.Path, c.input)
+		}
+	}
+	_, err = cheapjson.UnmarshalParallel([]byte(`[[[1]]]`), &cheapjson.ParseOptions{MaxDepth: 2}, 2)
+	var lerr *cheapjson.LimitError
+	assert.True(t, errors.As(err, &lerr))
+}
+
+func TestRangeLinesParallel(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10000; i++ {
+		lines = append(lines, fmt.Sprintf(`{"id": %d}`, i))
+		if i%100 == 0 {
+			lines = append(lines, "  ")
+		}
+	}
+	input := []byte(strings.Join(lines, "\n"))
+	next := 0
+	err := cheapjson.RangeLinesParallel(input, nil, 4, func(index int, value *cheapjson.Value) error {
+		assert.Equal(t, next, index)
+		assert.Equal(t, int64(index), value.Get("id").Int())
+		next++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 10000, next)
+
+	next = 0
+	err = cheapjson.RangeLinesParallel([]byte("1\n\n2\n{\"a\": x}\n3"), nil, 2, func(index int, value *cheapjson.Value) error {
+		next++
+		return nil
+	})
+	assert.Equal(t, 2, next)
+	var perr *cheapjson.ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, 4, perr.Line)
+		assert.Equal(t, 7, perr.Column)
+		assert.Equal(t, 11, perr.Offset)
+	}
+}
+
+func BenchmarkUnmarshalParallelBigArray(b *testing.B) {
+	input := bigArray()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		if _, err := cheapjson.UnmarshalParallel(input, nil, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalBigArray(b *testing.B) {
+	input := bigArray()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		if _, err := cheapjson.Unmarshal(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func bigArray() []byte {
+	var records []string
+	for i := 0; i < 20000; i++ {
+		records = append(records, string(normalInput))
+	}
+	return []byte("[" + strings.Join(records, ",") + "]")
+}
@@ -1,506 +1,389 @@
 package cheapjson
 
 import (
-	"bytes"
-	"errors"
-	"fmt"
-	"strconv"
+	"strings"
+	"sync"
 )
 
-func unexpected(expect string, offset, size int, data []byte) error {
-	if offset < size {
-		return errors.New(fmt.Sprintf("Unexptected token '%c' at: %d, expect: %s", data[offset], offset, expect))
-	} else {
-		return errors.New("Unexpected EOF, expect: " + expect)
-	}
+// Unmarshal parses a JSON text without any resource limit,
+// see UnmarshalWithOptions to parse untrusted input.
+func Unmarshal(data []byte) (*Value, error) {
+	return UnmarshalWithOptions(data, nil)
 }
 
-var (
-	valueStart = "{, [, [0-9], -, t, f, n, \""
-	bytesTrue  = []byte{'r', 'u', 'e'}
-	bytesFalse = []byte{'a', 'l', 's', 'e'}
-	bytesNull  = []byte{'u', 'l', 'l'}
-)
+// UnmarshalWithOptions parses a JSON text, and aborts with a
+// *ParseError wrapping a *LimitError as soon as any of the limits
+// of opts is exceeded. A nil opts means no limit.
+func UnmarshalWithOptions(data []byte, opts *ParseOptions) (*Value, error) {
+	return unmarshalAt(data, opts, 0)
+}
 
-const (
-	// start of a value
-	stateNone = iota
-	stateString
-	// after [ must be a value or ]
-	stateArrayValueOrEnd
-	// after a value, must be a , or ]
-	stateArrayEndOrComma
-	// after a {, must be a key string or }
-	stateObjectKeyOrEnd
-	// after a key string must be a :
-	stateObjectColon
-	// after a : must be a value
-	// after a value, must be , or }
-	stateObjectEndOrComma
-	// after a , must be key string
-	stateObjectKey
-)
+// unmarshalAt is UnmarshalWithOptions for the data at base
+// in the input, the spans are offset by base.
+func unmarshalAt(data []byte, opts *ParseOptions, base int) (*Value, error) {
+	p := parsers.Get().(*Parser)
+	p.opts = opts
+	value, err := p.parse(data, base)
+	p.opts = nil
+	parsers.Put(p)
+	return value, err
+}
 
-type state struct {
-	value  *Value
-	state  int
-	parent *state
+// the parsers reused by UnmarshalWithOptions, so the stacks and
+// the buffers do not grow from empty for each text
+var parsers = sync.Pool{
+	New: func() interface{} {
+		return &Parser{}
+	},
 }
 
-func addBuf(buf []byte, tempInt2, bufSize, ask int) ([]byte, int) {
-	if bufSize < tempInt2+ask {
-		bufSize += 1024
-		nbuf := make([]byte, bufSize)
-		copy(nbuf, buf)
-		return nbuf, bufSize
+// parse builds the only value of the text with the reset
+// tokenizer and builder.
+func parse(t *Tokenizer, b *valueBuilder) (*Value, error) {
+	if err := drive(t, b); err != nil {
+		b.flush()
+		return b.root, err
+	}
+	if t.offset = skipSpace(t.data, t.offset, t.opts.Relaxed); t.offset != len(t.data) {
+		return b.root, unexpected(expectEOF, t.offset, t.data, "$")
 	}
-	return buf, bufSize
-}
-
-func Unmarshal(data []byte) (value *Value, err error) {
-	value = &Value{nil}
-	root := &state{value, stateNone, nil}
-	curr := root
-	size := len(data)
-	offset := 0
-	bufSize := 1024
-	buf := make([]byte, bufSize)
-	tempUnicode := make([]int, 4)
-	var tempInt int
-	var tempInt2 int
-	var tempInt3 int
-	var tempInt4 int
-	var tempByte byte
-	var tempDecimal []byte
-	var tempExp []byte
+	return b.root, nil
+}
+
+// UnmarshalAll parses a sequence of JSON texts separated by
+// optional whitespace, such as the output of `jq -c`. Like the
+// Decoder, a number or a literal must be followed by whitespace
+// or a delimiter, so 1-2 is an error rather than 1 and -2. The
+// limits of opts apply to each value, except that MaxBytes
+// applies to the whole data.
+func UnmarshalAll(data []byte, opts *ParseOptions) ([]*Value, error) {
+	t := NewTokenizerWithOptions(data, opts)
+	data = t.data
+	var values []*Value
 	for {
-		// any loop start should check the whitespace
-	LOOP_WHITESPACE:
-		for ; offset < size; offset++ {
-			switch data[offset] {
-			case '\t', '\r', '\n', ' ':
-				continue
-			default:
-				break LOOP_WHITESPACE
+		if t.err == nil {
+			if t.offset = skipSpace(data, t.offset, t.opts.Relaxed); t.offset == len(data) {
+				return values, nil
 			}
 		}
-		if curr == nil {
-			// must end
-			if offset != size {
-				err = unexpected("EOF", offset, size, data)
-			}
-			// NO else, check it according to the context to
-			// get detailed information
-			return
+		start := t.offset
+		value, err := build(t)
+		if err != nil {
+			return values, err
 		}
-		switch curr.state {
-		case stateArrayValueOrEnd:
-			if offset == size {
-				err = unexpected("value or ]", offset, size, data)
-				return
-			}
-			switch data[offset] {
-			case ']':
-				curr = curr.parent
-				offset++
-			default:
-				curr.state = stateArrayEndOrComma
-				curr = &state{curr.value.AddElement(), stateNone, curr}
-			}
-			continue
-		case stateArrayEndOrComma:
-			if offset == size {
-				err = unexpected(", or ]", offset, size, data)
-				return
-			}
-			switch data[offset] {
-			case ']':
-				offset++
-				curr = curr.parent
-			case ',':
-				offset++
-				curr.state = stateArrayEndOrComma
-				curr = &state{curr.value.AddElement(), stateNone, curr}
-			default:
-				err = unexpected(", or ]", offset, size, data)
-				return
+		if !value.IsObject() && !value.IsArray() {
+			// the scalar ends where the Decoder finds its end
+			end := scanEnd(data, start, t.opts.Relaxed)
+			if end < 0 {
+				end = len(data)
 			}
-			continue
-		case stateObjectColon:
-			if offset == size {
-				err = unexpected(":", offset, size, data)
-				return
+			if end > t.offset {
+				return values, unexpected(expectEOF, t.offset, data, "$")
 			}
-			offset++
-			curr.state = stateNone
-			continue
-		case stateObjectEndOrComma:
-			if offset == size {
-				err = unexpected(", or }", offset, size, data)
-				return
-			}
-			switch data[offset] {
-			case ',':
-				curr.state = stateObjectKey
-			case '}':
-				curr = curr.parent
-			default:
-				err = unexpected(", or }", offset, size, data)
-				return
-			}
-			offset++
-			continue
-		case stateObjectKeyOrEnd:
-			if offset == size {
-				err = unexpected("\" or }", offset, size, data)
-				return
-			}
-			if data[offset] == '}' {
-				offset++
-				curr = curr.parent
-				continue
-			}
-			fallthrough
-		case stateObjectKey:
-			if offset == size {
-				err = unexpected("\"", offset, size, data)
-				return
-			}
-			if data[offset] != '"' {
-				err = unexpected("\"", offset, size, data)
-				return
-			}
-			fallthrough
-		case stateString:
-			offset++
-			tempInt2 = 0
-		LOOP_STRING:
-			for tempInt = offset; tempInt < size; tempInt++ {
-				switch data[tempInt] {
-				case '\\':
-					tempInt++
-					if tempInt == size {
-						err = unexpected("escaped char", tempInt, size, data)
-						return
-					}
-					switch data[tempInt] {
-					case 'U', 'u':
-						tempInt++
-						if size < tempInt+4 {
-							err = unexpected("[0-F]", size, size, data)
-							return
-						}
-						for tempInt3 = 0; tempInt3 < 4; tempInt3++ {
-							switch data[tempInt] {
-							case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-								tempUnicode[tempInt3] = int(data[tempInt]) - 0x30
-							case 'a', 'b', 'c', 'd', 'e', 'f':
-								tempUnicode[tempInt3] = int(data[tempInt]) - 0x57
-							case 'A', 'B', 'C', 'D', 'E', 'F':
-								tempUnicode[tempInt3] = int(data[tempInt]) - 0x37
-							default:
-								err = unexpected("[0-F]", tempInt, size, data)
-								return
-							}
-							tempInt++
-						}
-						tempInt4 = (tempUnicode[0] << 12) | (tempUnicode[1] << 8) | (tempUnicode[2] << 4) | (tempUnicode[3])
-						if tempInt4 > 0xD7FF && tempInt4 < 0xDC00 {
-							// need next utf-16 part
-							if size < tempInt+6 {
-								if size == tempInt || data[tempInt] != '\\' {
-									err = unexpected("\\", size, size, data)
-									return
-								}
-								if size < tempInt+2 || (data[tempInt+1] != 'U' && data[tempInt+1] != 'u') {
-									err = unexpected("Uu", tempInt+1, size, data)
-									return
-								}
-								err = unexpected("[0-F]", size, size, data)
-								return
-							}
-							if data[tempInt] != '\\' {
-								err = unexpected("\\", tempInt, size, data)
-								return
-							}
-							tempInt++
-							if data[tempInt] != 'U' && data[tempInt] != 'u' {
-								err = unexpected("Uu", tempInt, size, data)
-								return
-							}
-							tempInt++
-							for tempInt3 = 0; tempInt3 < 4; tempInt3++ {
-								switch data[tempInt] {
-								case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-									tempUnicode[tempInt3] = int(data[tempInt]) - 0x30
-								case 'a', 'b', 'c', 'd', 'e', 'f':
-									tempUnicode[tempInt3] = int(data[tempInt]) - 0x57
-								case 'A', 'B', 'C', 'D', 'E', 'F':
-									tempUnicode[tempInt3] = int(data[tempInt]) - 0x37
-								default:
-									err = unexpected("[0-F]", tempInt, size, data)
-									return
-								}
-								tempInt++
-							}
-							tempInt3 = (tempUnicode[0] << 12) | (tempUnicode[1] << 8) | (tempUnicode[2] << 4) | (tempUnicode[3])
-							if tempInt3 < 0xDC00 || tempInt3 > 0xDFFF {
-								err = unexpected("[0xdc00 - 0xdfff]", tempInt-4, size, data)
-								return
-							}
-							tempInt4 = (((tempInt4 - 0xD800) << 10) | (tempInt3 - 0xDC00)) + 0x10000
-						}
-						tempInt--
-						if tempInt4 < 0x0080 {
-							buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-							buf[tempInt2] = byte(tempInt4)
-							tempInt2++
-						} else if tempInt4 < 0x0800 {
-							buf, bufSize = addBuf(buf, tempInt2, bufSize, 2)
-							buf[tempInt2] = 0xC0 | byte(tempInt4>>6)
-							buf[tempInt2+1] = 0x80 | byte(tempInt4&0xBF)
-							tempInt2 += 2
-						} else if tempInt4 < 0x10000 {
-							buf, bufSize = addBuf(buf, tempInt2, bufSize, 3)
-							buf[tempInt2] = 0xE0 | byte(tempInt4>>12)
-							buf[tempInt2+1] = 0x80 | byte((tempInt4>>6)&0xBF)
-							buf[tempInt2+2] = 0x80 | byte(tempInt4&0xBF)
-							tempInt2 += 3
-						} else {
-							buf, bufSize = addBuf(buf, tempInt2, bufSize, 4)
-							buf[tempInt2] = 0xF0 | byte(tempInt4>>18)
-							buf[tempInt2+1] = 0x80 | byte((tempInt4>>12)&0xBF)
-							buf[tempInt2+2] = 0x80 | byte((tempInt4>>6)&0xBF)
-							buf[tempInt2+3] = 0x80 | byte(tempInt4&0xBF)
-							tempInt2 += 4
-						}
-					case 't':
-						buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-						buf[tempInt2] = '\t'
-						tempInt2++
-					case 'r':
-						buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-						buf[tempInt2] = '\r'
-						tempInt2++
-					case 'n':
-						buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-						buf[tempInt2] = '\n'
-						tempInt2++
-					case '"':
-						buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-						buf[tempInt2] = '"'
-						tempInt2++
-					case '\\':
-						buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-						buf[tempInt2] = '\\'
-						tempInt2++
-					case '/':
-						buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-						buf[tempInt2] = '/'
-						tempInt2++
-					case 'b':
-						buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-						buf[tempInt2] = 0x08
-						tempInt2++
-					case 'f':
-						buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-						buf[tempInt2] = 0x0C
-						tempInt2++
-					default:
-						err = unexpected("escape sequence", tempInt, size, data)
-						return
-					}
-				case '"':
-					break LOOP_STRING
-				case 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
-					16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31:
-					err = unexpected("unicode", tempInt, size, data)
-					return
-				default:
-					buf, bufSize = addBuf(buf, tempInt2, bufSize, 1)
-					buf[tempInt2] = data[tempInt]
-					tempInt2++
-				}
-			}
-			if tempInt == size {
-				err = unexpected("\" to end string", tempInt, size, data)
-				return
-			}
-			if curr.state == stateString {
-				curr.value.value = string(buf[0:tempInt2])
-				curr = curr.parent
-			} else {
-				curr.state = stateObjectEndOrComma
-				curr = &state{curr.value.AddField(string(buf[0:tempInt2])), stateObjectColon, curr}
-			}
-			offset = tempInt + 1
-			continue
-		default:
-			// the start of a value
-			// stateNone
-			if offset == size {
-				err = unexpected("value", offset, size, data)
-				return
-			}
-			switch data[offset] {
-			case '{':
-				curr.state = stateObjectKeyOrEnd
-				curr.value.value = map[string]*Value{}
-				offset++
-				continue
-			case '[':
-				curr.state = stateArrayValueOrEnd
-				curr.value.value = []*Value{}
-				offset++
-				continue
-			case '"':
-				curr.state = stateString
-				continue
-			case '0', '1', '2', '3', '4',
-				'5', '6', '7', '8', '9', '-':
-				tempDecimal = nil
-				tempExp = nil
-				tempInt4 = offset
-				// get negative
-				if data[offset] == '-' {
-					offset++
-				}
-			LOOP_NUM_INT: // read number.integer part
-				for tempInt = offset; tempInt < size; tempInt++ {
-					switch data[tempInt] {
-					case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-						continue
-					default:
-						break LOOP_NUM_INT
-					}
-				}
-				tempInt2 = tempInt - offset
-				// count of integer part
-				if tempInt2 == 0 {
-					// this will occur when start with -
-					err = unexpected("[0-9]", offset, size, data)
-					return
-				}
-				if data[offset] == '0' {
-					if tempInt2 != 1 {
-						// 0 MUST only one
-						offset++
-						err = unexpected("[.eE]", offset, size, data)
-						return
-					}
-				}
-				offset = tempInt
-				if offset < size && data[offset] == '.' {
-					// has decimal
-					offset++
-				LOOP_NUM_DEC:
-					for tempInt = offset; tempInt < size; tempInt++ {
-						switch data[tempInt] {
-						case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-							continue
-						default:
-							break LOOP_NUM_DEC
-						}
-					}
-					if tempInt == offset {
-						// MUST contains decimal
-						err = unexpected("[0-9]", offset, size, data)
-						return
-					}
-					tempDecimal = data[offset:tempInt]
-					offset = tempInt
-				}
-				if offset < size && (data[offset] == 'e' || data[offset] == 'E') {
-					// has exponent
-					offset++
-					if offset == size {
-						// need to check EOF for the leading +/-
-						err = unexpected("[0-9]", offset, size, data)
-						return
-					}
-					if data[offset] == '-' || data[offset] == '+' {
-						offset++
-					}
-
-				LOOP_NUM_EXP:
-					for tempInt = offset; tempInt < size; tempInt++ {
-						switch data[tempInt] {
-						case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-							continue
-						default:
-							break LOOP_NUM_EXP
-						}
-					}
-					if tempInt == offset {
-						err = unexpected("[0-9]", offset, size, data)
-						return
-					}
-					// There do not need to check the leading 0 according to the spec
-					tempExp = data[offset:tempInt]
-					offset = tempInt
-				}
-				// just simplify the cases, but we may need to confirm 1e3 is a integer
-				// rather than a float value
-				if tempDecimal == nil && tempExp == nil {
-					curr.value.value, err = strconv.ParseInt(string(data[tempInt4:offset]), 10, 64)
-				} else {
-					curr.value.value, err = strconv.ParseFloat(string(data[tempInt4:offset]), 64)
-				}
-				if err != nil {
-					return
-				}
-				// NORMAL to here
-				curr = curr.parent
-				continue
-			case 'n':
-				offset++
-				if size < offset+3 {
-					expect := bytesNull[size-offset]
-					err = unexpected(string(expect), size, size, data)
-					return
-				}
-				if bytes.Equal(data[offset:offset+3], bytesNull) {
-					offset += 3
-					curr.value.value = NULL
-					curr = curr.parent
-					continue
-				}
-				err = unexpected("null", offset, size, data)
-				return
-			case 't':
-				offset++
-				if size < offset+3 {
-					tempByte = bytesTrue[size-offset]
-					err = unexpected(string(tempByte), size, size, data)
-					return
-				}
-				if bytes.Equal(data[offset:offset+3], bytesTrue) {
-					offset += 3
-					curr.value.value = true
-					curr = curr.parent
-					continue
-				}
-				err = unexpected("true", offset, size, data)
-				return
-			case 'f':
-				offset++
-				if size < offset+4 {
-					tempByte = bytesFalse[size-offset]
-					err = unexpected(string(tempByte), size, size, data)
-					return
-				}
-				if bytes.Equal(data[offset:offset+4], bytesFalse) {
-					offset += 4
-					curr.value.value = false
-					curr = curr.parent
-					continue
-				}
-				err = unexpected("false", offset, size, data)
-				return
-			default:
-				err = unexpected(valueStart, offset, size, data)
-				return
+		}
+		values = append(values, value)
+		t.nodes = 0
+	}
+}
+
+// build reads the tokens of the next value and builds the tree,
+// the built part is returned even if an error occurs.
+func build(t *Tokenizer) (*Value, error) {
+	b := valueBuilder{}
+	b.reset(t, nil)
+	if err := drive(t, &b); err != nil {
+		b.flush()
+		return b.root, err
+	}
+	return b.root, nil
+}
+
+// valueBuilder is the Handler builds the Value tree.
+type valueBuilder struct {
+	root *Value
+	opts *ParseOptions
+	data []byte
+	// allocate the values from the arena if it is not nil
+	arena *arena
+	// the open containers
+	stack []*Value
+	// the start in elems of the elements of each open container,
+	// or -1 if it is an object
+	starts []int
+	// the elements of the open arrays, an array is set at its
+	// end to avoid growing it element by element
+	elems []*Value
+	key   string
+	// the value of the first occurrence of a duplicated key
+	duplicate *Value
+	// drop the value of the current key
+	drop bool
+	// the span of the token of the current event, and of the
+	// current key, if the Spans option is set
+	start    int
+	end      int
+	keyStart int
+	keyEnd   int
+	// the offset of the data in the input
+	base int
+}
+
+// reset prepares the builder for the text of t.
+func (b *valueBuilder) reset(t *Tokenizer, a *arena) {
+	*b = valueBuilder{
+		opts:   t.opts,
+		data:   t.data,
+		arena:  a,
+		stack:  b.stack[:0],
+		starts: b.starts[:0],
+		elems:  b.elems[:0],
+	}
+	b.root = b.newValue()
+}
+
+func (b *valueBuilder) newValue() *Value {
+	if b.arena != nil {
+		return b.arena.alloc()
+	}
+	return &Value{}
+}
+
+// clone copies the string passed to the Handler, unless it
+// refers to the input in the ZeroCopy mode.
+func (b *valueBuilder) clone(s string) string {
+	if b.opts.ZeroCopy && refers(b.data, s) {
+		return s
+	}
+	return strings.Clone(s)
+}
+
+// add returns the Value of the next element, field or root.
+func (b *valueBuilder) add() *Value {
+	value := b.addValue()
+	if b.opts.Spans {
+		b.setSpan(value)
+	}
+	return value
+}
+
+func (b *valueBuilder) addValue() *Value {
+	if len(b.stack) == 0 {
+		return b.root
+	}
+	top := len(b.stack) - 1
+	value := b.newValue()
+	if b.starts[top] >= 0 {
+		b.elems = append(b.elems, value)
+		return value
+	}
+	parent := b.stack[top]
+	if b.drop {
+		// build it detached
+		b.drop = false
+		return value
+	}
+	parent.addField(b.key, value)
+	if b.duplicate != nil {
+		parent.addDuplicate(b.key, b.duplicate, value)
+		b.duplicate = nil
+	}
+	return value
+}
+
+func (b *valueBuilder) onToken(tok Token) {
+	b.start = tok.Offset
+	b.end = tok.End
+}
+
+// setSpan records the span of the value starts at the current
+// token, the end of a container is set at its end.
+func (b *valueBuilder) setSpan(value *Value) {
+	if value.meta == nil {
+		value.meta = &meta{}
+	}
+	span := Span{Start: b.base + b.start, End: b.base + b.end}
+	if top := len(b.starts) - 1; top >= 0 && b.starts[top] < 0 {
+		span.KeyStart = b.base + b.keyStart
+		span.KeyEnd = b.base + b.keyEnd
+	}
+	value.meta.span = span
+	value.meta.spanned = true
+}
+
+// setEnd sets the end of the span of the container at the top.
+func (b *valueBuilder) setEnd() {
+	if b.opts.Spans {
+		b.stack[len(b.stack)-1].meta.span.End = b.base + b.end
+	}
+}
+
+func (b *valueBuilder) OnObjectStart() error {
+	value := b.add()
+	if b.opts.PreserveOrder {
+		value.AsOrderedObject()
+	} else {
+		value.value = map[string]*Value{}
+	}
+	b.stack = append(b.stack, value)
+	b.starts = append(b.starts, -1)
+	return nil
+}
+
+func (b *valueBuilder) OnKey(key string) error {
+	// the last value may be broken in UnmarshalRecover
+	b.drop = false
+	b.duplicate = nil
+	if b.opts.DuplicateKeys != DuplicateLastWins {
+		if first, ok := b.stack[len(b.stack)-1].value.(map[string]*Value)[key]; ok {
+			switch b.opts.DuplicateKeys {
+			case DuplicateFirstWins:
+				b.drop = true
+			case DuplicateError:
+				return &DuplicateKeyError{strings.Clone(key)}
+			case DuplicateCollect:
+				b.duplicate = first
 			}
 		}
 	}
+	b.key = b.clone(key)
+	b.keyStart = b.start
+	b.keyEnd = b.end
+	return nil
+}
+
+func (b *valueBuilder) OnObjectEnd() error {
+	b.setEnd()
+	b.stack = b.stack[:len(b.stack)-1]
+	b.starts = b.starts[:len(b.starts)-1]
+	return nil
+}
+
+func (b *valueBuilder) OnArrayStart() error {
+	b.stack = append(b.stack, b.add())
+	b.starts = append(b.starts, len(b.elems))
+	return nil
+}
+
+func (b *valueBuilder) OnArrayEnd() error {
+	b.setEnd()
+	top := len(b.stack) - 1
+	b.setElements(top, len(b.elems))
+	b.elems = b.elems[:b.starts[top]]
+	b.stack = b.stack[:top]
+	b.starts = b.starts[:top]
+	return nil
+}
+
+// setElements sets the array at i of the stack with
+// its elements ends at end in elems.
+func (b *valueBuilder) setElements(i, end int) {
+	elems := make([]*Value, end-b.starts[i])
+	copy(elems, b.elems[b.starts[i]:end])
+	clear(b.elems[b.starts[i]:end])
+	b.stack[i].value = elems
+}
+
+// flush sets the open arrays with the elements built
+// if the building is aborted.
+func (b *valueBuilder) flush() {
+	end := len(b.elems)
+	for i := len(b.stack) - 1; i >= 0; i-- {
+		if b.starts[i] >= 0 {
+			b.setElements(i, end)
+			end = b.starts[i]
+		}
+	}
+}
+
+func (b *valueBuilder) OnString(value string) error {
+	b.add().value = b.clone(value)
+	return nil
+}
+
+func (b *valueBuilder) OnInt(value int64) error {
+	b.add().value = value
+	return nil
+}
+
+func (b *valueBuilder) OnFloat(value float64) error {
+	b.add().value = value
+	return nil
+}
+
+func (b *valueBuilder) OnUint(value uint64) error {
+	b.add().value = value
+	return nil
+}
+
+func (b *valueBuilder) OnNumber(text string) error {
+	b.add().value = number(b.clone(text))
+	return nil
+}
+
+func (b *valueBuilder) OnBool(value bool) error {
+	b.add().value = value
+	return nil
+}
+
+func (b *valueBuilder) OnNull() error {
+	b.add().value = NULL
+	return nil
+}
+
+// Parser parses the JSON texts one by one reusing its buffers and
+// its state stack, such as to parse a lot of small messages. It is
+// not safe for concurrent use, keep one per goroutine or put them
+// in a sync.Pool.
+type Parser struct {
+	opts  *ParseOptions
+	t     Tokenizer
+	b     valueBuilder
+	arena *arena
+}
+
+// NewParser returns a parser with the options, the values
+// it returns are independent of the parser.
+func NewParser(opts *ParseOptions) *Parser {
+	if opts == nil {
+		opts = &defaultOptions
+	}
+	return &Parser{opts: opts}
+}
+
+// NewArenaParser returns a parser allocates the values from the
+// slabs it keeps, which are released at once by Reset, so a value
+// it returns must not be used after the next Reset.
+func NewArenaParser(opts *ParseOptions) *Parser {
+	p := NewParser(opts)
+	p.arena = &arena{}
+	return p
+}
+
+// Parse parses a JSON text like UnmarshalWithOptions.
+func (p *Parser) Parse(data []byte) (*Value, error) {
+	return p.parse(data, 0)
+}
+
+// parse is Parse for the data at base in the input.
+func (p *Parser) parse(data []byte, base int) (*Value, error) {
+	p.t.reset(data, p.opts)
+	p.b.reset(&p.t, p.arena)
+	p.b.base = base
+	value, err := parse(&p.t, &p.b)
+	// do not hold the input and the tree
+	p.t.data = nil
+	p.t.value = nil
+	clear(p.b.stack[:cap(p.b.stack)])
+	clear(p.b.elems[:cap(p.b.elems)])
+	p.b.root = nil
+	p.b.data = nil
+	return value, err
+}
+
+// Reset releases the values allocated by an arena parser,
+// the buffers are kept for the next text.
+func (p *Parser) Reset() {
+	if p.arena != nil {
+		p.arena.reset()
+	}
 }
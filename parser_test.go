@@ -215,3 +215,39 @@ func BenchmarkCompareMarshal(b *testing.B) {
 		},
 	}, "./json-test-suite/correct")
 }
+
+func TestParser(t *testing.T) {
+	for _, parser := range []*cheapjson.Parser{cheapjson.NewParser(nil), cheapjson.NewArenaParser(nil)} {
+		first, err := parser.Parse([]byte(`{"a": [1, "x\n", {"b": null}]}`))
+		assert.Nil(t, err)
+		second, err := parser.Parse([]byte(`[true, 2.5]`))
+		assert.Nil(t, err)
+		_, err = parser.Parse([]byte(`[1, ]`))
+		assert.NotNil(t, err)
+		_, err = parser.Parse([]byte(`[1] 2`))
+		assert.NotNil(t, err)
+		// the values are valid until Reset
+		assert.Equal(t, "x\n", first.Get("a", "1").String())
+		assert.True(t, first.Get("a", "2", "b").IsNull())
+		assert.Equal(t, 2.5, second.Get("1").Float())
+		parser.Reset()
+		value, err := parser.Parse(normalInput)
+		assert.Nil(t, err)
+		output, err := json.MarshalIndent(value.Value(), "", "  ")
+		assert.Nil(t, err)
+		assert.Equal(t, normalInput, output)
+	}
+
+	parser := cheapjson.NewArenaParser(&cheapjson.ParseOptions{ZeroCopy: true})
+	data := []byte(`[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, "a", "b", "c"]`)
+	allocs := testing.AllocsPerRun(10, func() {
+		parser.Reset()
+		_, _ = parser.Parse(data)
+	})
+	copied := testing.AllocsPerRun(10, func() {
+		_, _ = cheapjson.Unmarshal(data)
+	})
+	// the slabs and the buffers are reused between the texts
+	assert.LessOrEqual(t, allocs, 8.0)
+	assert.Less(t, allocs, copied/4)
+}
@@ -0,0 +1,199 @@
+package cheapjson
+
+import (
+	"io"
+	"strconv"
+)
+
+// UnmarshalPaths parses a JSON text and returns the values at the
+// paths, which are resolved like Value.Get, the value of a path
+// which does not exist is nil. The text is read once, and only the
+// values at the paths are built, the others are skipped like
+// Tokenizer.Skip, so they are only checked for the brackets and
+// the quotes.
+func UnmarshalPaths(data []byte, paths ...[]string) ([]*Value, error) {
+	return UnmarshalPathsWithOptions(data, nil, paths...)
+}
+
+// UnmarshalPathsWithOptions is UnmarshalPaths with the options, the
+// DuplicateKeys option decides which value of a duplicated key on
+// the paths is returned.
+func UnmarshalPathsWithOptions(data []byte, opts *ParseOptions, paths ...[]string) ([]*Value, error) {
+	root := &pathNode{}
+	for i, path := range paths {
+		root.add(path, i)
+	}
+	w := pathWalker{t: NewTokenizerWithOptions(data, opts), values: make([]*Value, len(paths))}
+	tok, err := w.t.Next()
+	if err == io.EOF {
+		err = w.t.unexpected(expectValue, w.t.offset)
+	}
+	if err == nil {
+		err = w.walk(tok, root)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if end := skipSpace(w.t.data, w.t.offset, w.t.opts.Relaxed); end != len(w.t.data) {
+		return nil, unexpected(expectEOF, end, w.t.data, "$")
+	}
+	return w.values, nil
+}
+
+// pathNode is a node of the trie of the paths.
+type pathNode struct {
+	children map[string]*pathNode
+	// the index of the key in an array, or -1
+	index int
+	// the paths end at the node
+	ends []int
+	// the object in which the key is matched last
+	seen int
+}
+
+func (n *pathNode) add(path []string, i int) {
+	for _, key := range path {
+		child := n.children[key]
+		if child == nil {
+			child = &pathNode{index: -1}
+			if index, err := strconv.Atoi(key); err == nil && index >= 0 {
+				child.index = index
+			}
+			if n.children == nil {
+				n.children = map[string]*pathNode{}
+			}
+			n.children[key] = child
+		}
+		n = child
+	}
+	n.ends = append(n.ends, i)
+}
+
+// set sets the values of the paths under the node by the value
+// at the node, which is nil if the node is not matched.
+func (n *pathNode) set(values []*Value, value *Value) {
+	for _, i := range n.ends {
+		values[i] = value
+	}
+	for key, child := range n.children {
+		var next *Value
+		if value != nil {
+			next = value.Get(key)
+		}
+		child.set(values, next)
+	}
+}
+
+type pathWalker struct {
+	t      *Tokenizer
+	values []*Value
+	// the count of the walked objects
+	objects int
+}
+
+// walk reads the value starts with tok, builds it if a path ends
+// at the node, else walks the children on the paths.
+func (w *pathWalker) walk(tok Token, n *pathNode) error {
+	if len(n.ends) > 0 {
+		value, err := w.build(tok)
+		if err != nil {
+			return err
+		}
+		n.set(w.values, value)
+		return nil
+	}
+	switch tok.Kind {
+	case TokenBeginObject:
+		return w.walkObject(n)
+	case TokenBeginArray:
+		return w.walkArray(n)
+	}
+	return nil
+}
+
+func (w *pathWalker) walkObject(n *pathNode) error {
+	t := w.t
+	w.objects++
+	object := w.objects
+	for {
+		key, err := t.Next()
+		if err != nil {
+			return err
+		}
+		if key.Kind == TokenEndObject {
+			return nil
+		}
+		child := n.children[string(key.Value)]
+		if child != nil && child.seen == object {
+			switch t.opts.DuplicateKeys {
+			case DuplicateFirstWins:
+				child = nil
+			case DuplicateError:
+				return t.abort(&DuplicateKeyError{string(key.Value)}, key)
+			default:
+				child.set(w.values, nil)
+			}
+		}
+		tok, err := t.Next()
+		if err != nil {
+			return err
+		}
+		if child == nil {
+			if _, err = t.skipValue(tok); err != nil {
+				return err
+			}
+			continue
+		}
+		child.seen = object
+		if err = w.walk(tok, child); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *pathWalker) walkArray(n *pathNode) error {
+	t := w.t
+	var matches []*pathNode
+	for i := 0; ; i++ {
+		tok, err := t.Next()
+		if err != nil {
+			return err
+		}
+		if tok.Kind == TokenEndArray {
+			return nil
+		}
+		// the keys such as "1" and "01" match the same element
+		matches = matches[:0]
+		for _, child := range n.children {
+			if child.index == i {
+				matches = append(matches, child)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			_, err = t.skipValue(tok)
+		case 1:
+			err = w.walk(tok, matches[0])
+		default:
+			var value *Value
+			if value, err = w.build(tok); err == nil {
+				for _, child := range matches {
+					child.set(w.values, value)
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// build builds the value starts with tok.
+func (w *pathWalker) build(tok Token) (*Value, error) {
+	b := valueBuilder{}
+	b.reset(w.t, nil)
+	if err := driveValue(w.t, &b, tok); err != nil {
+		return nil, err
+	}
+	return b.root, nil
+}
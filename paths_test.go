@@ -0,0 +1,69 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalPaths(t *testing.T) {
+	input := []byte(`{"skip": [nul], "user": {"name": "a", "tags": ["x", "y"]}, "list": [1, {"b": 2}], "dup": 1, "dup": {"c": 3}}`)
+	values, err := cheapjson.UnmarshalPaths(input,
+		[]string{"user", "name"},
+		[]string{"user"},
+		[]string{"user", "tags", "1"},
+		[]string{"list", "1", "b"},
+		[]string{"list", "01"},
+		[]string{"list", "2"},
+		[]string{"none", "a"},
+		[]string{"user", "name", "a"},
+		[]string{"dup", "c"},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, len(values))
+	assert.Equal(t, "a", values[0].String())
+	assert.Equal(t, "y", values[1].Get("tags", "1").String())
+	assert.Equal(t, "y", values[2].String())
+	assert.Equal(t, int64(2), values[3].Int())
+	assert.Equal(t, int64(2), values[4].Get("b").Int())
+	assert.Nil(t, values[5])
+	assert.Nil(t, values[6])
+	assert.Nil(t, values[7])
+	assert.Equal(t, int64(3), values[8].Int())
+
+	// the whole text
+	values, err = cheapjson.UnmarshalPaths([]byte(` 1 `), []string{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), values[0].Int())
+
+	// the duplicated keys
+	input = []byte(`{"a": {"b": 1}, "a": 2}`)
+	values, err = cheapjson.UnmarshalPaths(input, []string{"a", "b"})
+	assert.Nil(t, err)
+	assert.Nil(t, values[0])
+	values, err = cheapjson.UnmarshalPathsWithOptions(input, &cheapjson.ParseOptions{DuplicateKeys: cheapjson.DuplicateFirstWins}, []string{"a", "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), values[0].Int())
+	_, err = cheapjson.UnmarshalPathsWithOptions(input, &cheapjson.ParseOptions{DuplicateKeys: cheapjson.DuplicateError}, []string{"a"})
+	var kerr *cheapjson.DuplicateKeyError
+	assert.True(t, errors.As(err, &kerr))
+
+	for _, input := range []string{``, `{"a": [1}`, `{"a": 1} 2`, `{"a": tru}`} {
+		_, err = cheapjson.UnmarshalPaths([]byte(input), []string{"a"})
+		assert.NotNil(t, err, input)
+	}
+	_, err = cheapjson.UnmarshalPaths([]byte(`{"a": {"b": 1 "c": 2}}`), []string{"a", "c"})
+	var perr *cheapjson.ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, 14, perr.Offset)
+		assert.Equal(t, "$.a", perr.Path)
+	}
+}
+
+func BenchmarkUnmarshalPathsNormalInput(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = cheapjson.UnmarshalPaths(normalInput, []string{"string"}, []string{"int"}, []string{"float"})
+	}
+}
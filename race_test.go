@@ -0,0 +1,7 @@
+//go:build race
+
+package cheapjson_test
+
+func init() {
+	race = true
+}
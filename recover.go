@@ -0,0 +1,127 @@
+package cheapjson
+
+// UnmarshalRecover parses a JSON text like UnmarshalWithOptions,
+// but it does not stop at a syntax error, such as for a linter
+// to report all the problems of a file at once. The broken value
+// is skipped to the next , ] or } of its container, a mismatched
+// ] or } closes the current container only, and the containers
+// open at the end of data are closed. Returns the best-effort tree and
+// the errors in the order of the input. A limit error, an error
+// outside of any container and the DuplicateError still stop the
+// parsing, they are the last error.
+func UnmarshalRecover(data []byte, opts *ParseOptions) (*Value, []*ParseError) {
+	t := NewTokenizerWithOptions(data, opts)
+	data = t.data
+	t.recover = true
+	value, err := build(t)
+	if err == nil {
+		if t.offset = skipSpace(data, t.offset, t.opts.Relaxed); t.offset != len(data) {
+			err = unexpected(expectEOF, t.offset, data, "$")
+		}
+	}
+	if err != nil {
+		t.errs = append(t.errs, err.(*ParseError))
+	}
+	return value, t.errs
+}
+
+// recovering records err and reports if the tokenizer could
+// resync from it.
+func (t *Tokenizer) recovering(err error) bool {
+	perr, ok := err.(*ParseError)
+	if !t.recover || !ok || perr.Err != nil || len(t.stack) == 0 {
+		return false
+	}
+	t.errs = append(t.errs, perr)
+	return true
+}
+
+// resync skips the broken value at t.offset to the next , ] or }
+// of the current container, and sets the state to read it. A
+// mismatched ] or } is skipped and closes the current container,
+// and at the end of data all the containers are closed, by the
+// next calls of Next.
+func (t *Tokenizer) resync() {
+	data := t.data
+	size := len(data)
+	t.inValue = false
+	top := &t.stack[len(t.stack)-1]
+	array := top.state == stateArrayValueOrEnd || top.state == stateArrayEndOrComma
+	if array {
+		top.state = stateArrayEndOrComma
+	} else {
+		top.state = stateObjectEndOrComma
+	}
+	depth := 0
+	for offset := t.offset; offset < size; offset++ {
+		switch c := data[offset]; c {
+		case '"', '\'':
+			if c == '"' || t.opts.Relaxed {
+				offset = skipBroken(data, offset)
+			}
+		case '/':
+			if t.opts.Relaxed && isComment(data[offset:]) {
+				if end := skipComments(data, offset); end > offset {
+					offset = end - 1
+				} else {
+					// unterminated
+					offset = size - 1
+				}
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth > 0 {
+				depth--
+				break
+			}
+			t.offset = offset
+			if (c == ']') == array {
+				return
+			}
+			// a mismatched one only closes the current container,
+			// so the outer ones are read on
+			t.offset++
+			t.unwind = 1
+			return
+		case ',':
+			if depth == 0 {
+				t.offset = offset
+				return
+			}
+		}
+	}
+	t.offset = size
+	t.unwind = len(t.stack)
+}
+
+// close closes the current container without reading any
+// byte, and returns an empty end token.
+func (t *Tokenizer) close() Token {
+	t.unwind--
+	top := t.stack[len(t.stack)-1].state
+	t.stack = t.stack[:len(t.stack)-1]
+	tok := Token{Kind: TokenEndObject, Offset: t.offset, End: t.offset}
+	if top == stateArrayValueOrEnd || top == stateArrayEndOrComma {
+		tok.Kind = TokenEndArray
+	}
+	return tok
+}
+
+// skipBroken returns the offset of the closing quote of the
+// string starts at offset, or the end of the line if the quote
+// is missing, as the strings could not contain a line feed.
+func skipBroken(data []byte, offset int) int {
+	quote := data[offset]
+	for offset++; offset < len(data); offset++ {
+		switch data[offset] {
+		case '\\':
+			offset++
+		case quote:
+			return offset
+		case '\n':
+			return offset - 1
+		}
+	}
+	return offset
+}
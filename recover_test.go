@@ -0,0 +1,75 @@
+package cheapjson_test
+
+import (
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalRecover(t *testing.T) {
+	input := []byte(`{
+  "a": [1, tru, 3, "b\q", [4 5], {"x" 1, "y": 2}],
+  "c" 2,
+  "d": {"e": 1],
+  "f": [1, {"g": 2
+`)
+	value, errs := cheapjson.UnmarshalRecover(input, nil)
+	output, err := value.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":[1,3,[4],{"y":2}],"d":{"e":1},"f":[1,{"g":2}]}`, string(output))
+	var offsets []int
+	var paths []string
+	for _, err := range errs {
+		offsets = append(offsets, err.Offset)
+		paths = append(paths, err.Path)
+	}
+	assert.Equal(t, []int{13, 24, 31, 40, 59, 76, 98}, offsets)
+	assert.Equal(t, []string{"$.a[1]", "$.a[3]", "$.a[4]", "$.a[5].x", "$.c", "$.d", "$.f[1]"}, paths)
+	assert.Equal(t, 6, errs[len(errs)-1].Line)
+
+	// the ] closes the inner object
+	value, errs = cheapjson.UnmarshalRecover([]byte(`[{"a": ], 2, "x,]" x, "y"]`), nil)
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, `[{},2,"x,]","y"]`, string(must(value.MarshalJSON())))
+
+	// a mismatched closer only closes the current container,
+	// the errors after it are still collected
+	value, errs = cheapjson.UnmarshalRecover([]byte(`{"a": [1, 2 3, }, "b": tru, "c": {"d": ]}`), nil)
+	offsets = nil
+	paths = nil
+	for _, err := range errs {
+		offsets = append(offsets, err.Offset)
+		paths = append(paths, err.Path)
+	}
+	assert.Equal(t, []int{12, 15, 23, 39}, offsets)
+	assert.Equal(t, []string{"$.a", "$.a[2]", "$.b", "$.c.d"}, paths)
+	assert.Equal(t, `{"a":[1,2],"c":{}}`, string(must(value.MarshalJSON())))
+
+	value, errs = cheapjson.UnmarshalRecover([]byte(`[1, 2] [3]`), nil)
+	assert.Equal(t, int64(2), value.Get("1").Int())
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, 7, errs[0].Offset)
+
+	value, errs = cheapjson.UnmarshalRecover([]byte(`[1, 2]`), nil)
+	assert.Nil(t, errs)
+	assert.Equal(t, 2, len(value.Array()))
+
+	value, errs = cheapjson.UnmarshalRecover([]byte(`[[[[1]]]]`), &cheapjson.ParseOptions{MaxDepth: 2})
+	assert.Equal(t, 1, len(errs))
+	assert.NotNil(t, errs[0].Err)
+	assert.Equal(t, 1, len(value.Array()))
+
+	value, errs = cheapjson.UnmarshalRecover([]byte(`{a: 1, b: [2,, 3] /* ] */, c: 'x]'}`), &cheapjson.ParseOptions{Relaxed: true})
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, int64(1), value.Get("a").Int())
+	assert.Equal(t, int64(2), value.Get("b", "0").Int())
+	assert.Equal(t, "x]", value.Get("c").String())
+}
+
+func must(data []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,221 @@
+package cheapjson
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The JSON5 syntax of the Relaxed option. The readers are only
+// called if the option is set, so the strict path is not slowed.
+
+// readRelaxedNumber reads the number starts at offset in the JSON5
+// syntax, returns the end of the number. t.float reports if it is
+// a float, and t.relaxed reports if it is not a JSON number.
+func (t *Tokenizer) readRelaxedNumber(offset int) (int, error) {
+	data := t.data
+	size := len(data)
+	t.float = false
+	t.relaxed = false
+	switch data[offset] {
+	case '+':
+		t.relaxed = true
+		offset++
+	case '-':
+		offset++
+	}
+	if offset < size {
+		switch data[offset] {
+		case 'I', 'N':
+			literal, expect := bytesInfinity, expectInfinity
+			if data[offset] == 'N' {
+				literal, expect = bytesNaN, expectNaN
+			}
+			if !bytes.HasPrefix(data[offset:], literal) {
+				return offset, t.unexpected(expect, offset)
+			}
+			t.float = true
+			t.relaxed = true
+			return offset + len(literal), nil
+		case '0':
+			if offset+1 < size && (data[offset+1] == 'x' || data[offset+1] == 'X') {
+				offset += 2
+				start := offset
+				for ; offset < size && isHexDigit(data[offset]); offset++ {
+				}
+				if offset == start {
+					return offset, t.unexpected(expectHex, offset)
+				}
+				t.relaxed = true
+				return offset, nil
+			}
+		}
+	}
+	start := offset
+	for ; offset < size && data[offset] >= '0' && data[offset] <= '9'; offset++ {
+	}
+	integer := offset - start
+	if integer > 1 && data[start] == '0' {
+		return offset, t.unexpected(expectFraction, start+1)
+	}
+	if offset < size && data[offset] == '.' {
+		offset++
+		start = offset
+		for ; offset < size && data[offset] >= '0' && data[offset] <= '9'; offset++ {
+		}
+		if offset == start && integer == 0 {
+			return offset, t.unexpected(expectDigit, offset)
+		}
+		// .5 or 5.
+		t.relaxed = t.relaxed || offset == start || integer == 0
+		t.float = true
+	} else if integer == 0 {
+		return offset, t.unexpected(expectDigit, offset)
+	}
+	if offset < size && (data[offset] == 'e' || data[offset] == 'E') {
+		offset++
+		if offset < size && (data[offset] == '-' || data[offset] == '+') {
+			offset++
+		}
+		start = offset
+		for ; offset < size && data[offset] >= '0' && data[offset] <= '9'; offset++ {
+		}
+		if offset == start {
+			return offset, t.unexpected(expectDigit, offset)
+		}
+		t.float = true
+	}
+	return offset, nil
+}
+
+// readRelaxedString reads the string starts after the quote at
+// offset, the quote is " or '. It is readString with the escapes
+// of JSON5, such as \x41, \v, \0 and the line continuation, and
+// the control chars other than the line terminators are allowed.
+func (t *Tokenizer) readRelaxedString(offset int, quote byte) (int, error) {
+	data := t.data
+	size := len(data)
+	start := offset
+	var buf []byte
+	// buf holds the decoded string
+	escaped := false
+	var err error
+	for ; offset < size; offset++ {
+		c := data[offset]
+		switch c {
+		case quote:
+			if !escaped {
+				t.value = data[start:offset]
+			} else {
+				t.buf = buf
+				t.value = buf
+			}
+			if t.opts.MaxStringLen > 0 && len(t.value) > t.opts.MaxStringLen {
+				return offset, t.exceeded("MaxStringLen", t.opts.MaxStringLen, start-1)
+			}
+			return offset + 1, nil
+		case '\n', '\r':
+			return offset, t.unexpected(expectEscapedNewline, offset)
+		case '\\':
+			if !escaped {
+				buf = append(t.buf[:0], data[start:offset]...)
+				escaped = true
+			}
+			offset++
+			if offset == size {
+				return offset, t.unexpected(expectEscape, offset)
+			}
+			switch data[offset] {
+			case 'U', 'u':
+				if buf, offset, err = t.readUnicode(buf, offset+1); err != nil {
+					return offset, err
+				}
+				offset--
+			case 'x':
+				code, err := t.readHex(offset+1, 2)
+				if err != nil {
+					return offset, err
+				}
+				buf = appendCode(buf, code)
+				offset += 2
+			case 't':
+				buf = append(buf, '\t')
+			case 'r':
+				buf = append(buf, '\r')
+			case 'n':
+				buf = append(buf, '\n')
+			case 'b':
+				buf = append(buf, 0x08)
+			case 'f':
+				buf = append(buf, 0x0C)
+			case 'v':
+				buf = append(buf, 0x0B)
+			case '0':
+				if offset+1 < size && data[offset+1] >= '0' && data[offset+1] <= '9' {
+					return offset, t.unexpected(expectEscape, offset)
+				}
+				buf = append(buf, 0)
+			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				return offset, t.unexpected(expectEscape, offset)
+			case '\r':
+				// the line continuation
+				if offset+1 < size && data[offset+1] == '\n' {
+					offset++
+				}
+			case '\n':
+			default:
+				if r, n := utf8.DecodeRune(data[offset:]); r == '\u2028' || r == '\u2029' {
+					offset += n - 1
+				} else {
+					// any other char escapes itself, such as \'
+					buf = append(buf, data[offset])
+				}
+			}
+		default:
+			if c >= utf8.RuneSelf && !escaped && t.opts.InvalidUTF8 != UTF8Pass {
+				if n := validRune(data, offset); n > 0 {
+					offset += n - 1
+					break
+				}
+				if t.opts.InvalidUTF8 == UTF8Reject {
+					return offset, t.unexpected(expectUTF8, offset)
+				}
+				buf = append(t.buf[:0], data[start:offset]...)
+				escaped = true
+			}
+			if !escaped {
+				break
+			}
+			if c < utf8.RuneSelf {
+				buf = append(buf, c)
+			} else if buf, offset, err = t.appendRune(buf, offset); err != nil {
+				return offset, err
+			}
+		}
+	}
+	if escaped {
+		t.buf = buf
+	}
+	return offset, t.unexpected([]string{string(quote)}, offset)
+}
+
+// readIdentifier returns the end of the unquoted key starts at
+// offset, or offset if it is not an identifier. The escapes in
+// the identifiers are not supported.
+func readIdentifier(data []byte, offset int) int {
+	for start := offset; offset < len(data); {
+		r, n := utf8.DecodeRune(data[offset:])
+		if r == '$' || r == '_' || unicode.IsLetter(r) ||
+			offset > start && (unicode.IsDigit(r) || r == '\u200C' || r == '\u200D' ||
+				unicode.In(r, unicode.Mn, unicode.Mc, unicode.Pc)) {
+			offset += n
+			continue
+		}
+		break
+	}
+	return offset
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
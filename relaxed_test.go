@@ -0,0 +1,127 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+var relaxed = &cheapjson.ParseOptions{Relaxed: true}
+
+func TestRelaxed(t *testing.T) {
+	input := []byte(`// the config
+{
+  /* the server */
+  server: {
+    host: 'localhost', // single quoted
+    port: 0x1F90,
+    'tls': false,
+  },
+  $ratio_1: +.5,
+  retries: 3.,
+  limits: [Infinity, -Infinity, NaN, -12e-1,],
+  "escapes": 'it\'s \x41\v\0 \
+ok',
+  empty: {},
+}
+/* the end */`)
+	value, err := cheapjson.UnmarshalWithOptions(input, relaxed)
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", value.Get("server", "host").String())
+	assert.Equal(t, int64(8080), value.Get("server", "port").Int())
+	assert.True(t, value.Get("server", "tls").IsFalse())
+	assert.Equal(t, 0.5, value.Get("$ratio_1").Float())
+	assert.Equal(t, 3.0, value.Get("retries").Float())
+	assert.True(t, math.IsInf(value.Get("limits", "0").Float(), 1))
+	assert.True(t, math.IsInf(value.Get("limits", "1").Float(), -1))
+	assert.True(t, math.IsNaN(value.Get("limits", "2").Float()))
+	assert.Equal(t, -1.2, value.Get("limits", "3").Float())
+	assert.Equal(t, 4, len(value.Get("limits").Array()))
+	assert.Equal(t, "it's A\v\x00 ok", value.Get("escapes").String())
+	assert.Equal(t, []string{}, value.Get("empty").Keys())
+
+	// the relaxed numbers are converted even in the raw mode
+	value, err = cheapjson.UnmarshalWithOptions([]byte(`[0x10, +1, 1.5, -NaN]`), &cheapjson.ParseOptions{Relaxed: true, Numbers: cheapjson.NumberRaw})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(16), value.Get("0").Int())
+	assert.Equal(t, int64(1), value.Get("1").Int())
+	assert.True(t, value.Get("2").IsRawNumber())
+	assert.True(t, math.IsNaN(value.Get("3").Float()))
+	value, err = cheapjson.UnmarshalWithOptions([]byte(`[0xFFFFFFFFFFFFFFFF, +2.0]`), &cheapjson.ParseOptions{Relaxed: true, Numbers: cheapjson.NumberIntegral})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), value.Get("0").Uint())
+	assert.Equal(t, int64(2), value.Get("1").Int())
+
+	// the strict mode is not changed
+	for _, input := range []string{`[1,]`, `{a:1}`, `'a'`, `+1`, `.5`, `5.`, `0x1F`, `NaN`, `[1 /* c */]`} {
+		_, err = cheapjson.Unmarshal([]byte(input))
+		assert.NotNil(t, err, input)
+	}
+
+	for _, c := range []struct {
+		input  string
+		offset int
+		path   string
+	}{
+		{`[1,,]`, 3, "$[1]"},
+		{`{,}`, 1, "$"},
+		{`{a:1,,}`, 5, "$"},
+		{`{a b:1}`, 3, "$.a"},
+		{`{'a\'b':01}`, 9, `$["a'b"]`},
+		{`['a` + "\n" + `']`, 3, "$[0]"},
+		{`'\1'`, 2, "$"},
+		{`[0x]`, 3, "$[0]"},
+		{`[Infinit]`, 1, "$[0]"},
+		{`[.]`, 2, "$[0]"},
+		{`[1 /* c ]`, 3, "$"},
+	} {
+		_, err = cheapjson.UnmarshalWithOptions([]byte(c.input), relaxed)
+		var perr *cheapjson.ParseError
+		if assert.True(t, errors.As(err, &perr), c.input) {
+			assert.Equal(t, c.offset, perr.Offset, c.input)
+			assert.Equal(t, c.path, perr.Path, c.input)
+		}
+	}
+}
+
+func TestRelaxedStream(t *testing.T) {
+	tokenizer := cheapjson.NewTokenizerWithOptions([]byte(`{a: [']', /* ] */ 1], b: 2}`), relaxed)
+	tok, err := tokenizer.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, cheapjson.TokenBeginObject, tok.Kind)
+	assert.Nil(t, tokenizer.Skip())
+	tok, err = tokenizer.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "b", string(tok.Value))
+
+	input := "// first\n{a: '}'} /* second */ [1, // ]\n 2,]\n'three' // the end"
+	decoder := cheapjson.NewDecoderWithOptions(iotest.OneByteReader(strings.NewReader(input)), relaxed)
+	var values []interface{}
+	for decoder.More() {
+		value, err := decoder.Decode()
+		assert.Nil(t, err)
+		values = append(values, value.Value())
+	}
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"a": "}"},
+		[]interface{}{int64(1), int64(2)},
+		"three",
+	}, values)
+	_, err = decoder.Decode()
+	assert.Equal(t, io.EOF, err)
+
+	reader := cheapjson.NewLineReaderWithOptions(strings.NewReader("{a: 1}\n// skipped\n[2,]\n"), relaxed)
+	value, err := reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), value.Get("a").Int())
+	value, err = reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, reader.Line())
+	assert.Equal(t, int64(2), value.Get("0").Int())
+}
@@ -0,0 +1,221 @@
+package cheapjson
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	bytesLineComment = []byte{'/', '/'}
+	bytesCommentEnd  = []byte{'*', '/'}
+)
+
+// endScanner finds the end of a value. It only matches the
+// brackets and quotes, so it is much cheaper than parsing, and
+// the syntax of the value should be checked by the parser. It
+// could be resumed when more data is appended.
+type endScanner struct {
+	// the next byte to scan
+	offset  int
+	depth   int
+	started bool
+	// the quote of the current string, or 0
+	quote   byte
+	escaped bool
+	// also match the single quotes and the comments of JSON5
+	relaxed bool
+	// the second byte of the current comment, / or *, or 0
+	comment byte
+}
+
+// scan returns the end offset of the value starts at data[0],
+// or -1 if data ends before the value. A number or literal
+// ends at the first delimiter, so it is treated as unfinished
+// if it reaches the end of data.
+func (s *endScanner) scan(data []byte) int {
+	size := len(data)
+	for ; s.offset < size; s.offset++ {
+		if s.quote != 0 {
+			if s.escaped {
+				s.escaped = false
+				continue
+			}
+			// skip to the next quote or backslash
+			for s.offset < size && data[s.offset] != s.quote && data[s.offset] != '\\' {
+				s.offset++
+			}
+			if s.offset == size {
+				break
+			}
+			if data[s.offset] == '\\' {
+				s.escaped = true
+				continue
+			}
+			s.quote = 0
+			if s.depth == 0 {
+				s.offset++
+				return s.offset
+			}
+			continue
+		}
+		if s.comment != 0 {
+			if s.comment == '/' && data[s.offset] == '\n' {
+				s.comment = 0
+			} else if s.comment == '*' && data[s.offset] == '*' {
+				if s.offset+1 == size {
+					// wait for the next byte
+					return -1
+				}
+				if data[s.offset+1] == '/' {
+					s.comment = 0
+					s.offset++
+				}
+			}
+			continue
+		}
+		switch data[s.offset] {
+		case '"', '{', '[':
+			if s.depth == 0 && s.started {
+				// a scalar followed by a value
+				return s.offset
+			}
+			if data[s.offset] == '"' {
+				s.quote = '"'
+			} else {
+				s.depth++
+			}
+		case '\'':
+			if !s.relaxed {
+				break
+			}
+			if s.depth == 0 && s.started {
+				return s.offset
+			}
+			s.quote = '\''
+		case '/':
+			if !s.relaxed {
+				break
+			}
+			if s.offset+1 == size {
+				return -1
+			}
+			if c := data[s.offset+1]; c == '/' || c == '*' {
+				if s.depth == 0 && s.started {
+					return s.offset
+				}
+				s.comment = c
+				s.offset++
+				continue
+			}
+		case '}', ']':
+			if s.depth == 0 && s.started {
+				return s.offset
+			}
+			s.depth--
+			if s.depth <= 0 {
+				s.offset++
+				return s.offset
+			}
+		case '\t', '\r', '\n', ' ', ',', ':':
+			if s.depth == 0 {
+				if !s.started {
+					s.offset++
+				}
+				return s.offset
+			}
+		}
+		s.started = true
+	}
+	return -1
+}
+
+// scanEnd returns the end offset of the value starts at
+// offset, or -1 if data ends before the value.
+func scanEnd(data []byte, offset int, relaxed bool) int {
+	s := endScanner{relaxed: relaxed}
+	if end := s.scan(data[offset:]); end >= 0 {
+		return offset + end
+	}
+	return -1
+}
+
+// skipWhitespace returns the offset of the first non
+// whitespace byte since offset.
+func skipWhitespace(data []byte, offset int) int {
+	for ; offset < len(data); offset++ {
+		if !whitespace[data[offset]] {
+			return offset
+		}
+	}
+	return offset
+}
+
+// whitespace is faster than a switch for the indented texts
+var whitespace = [256]bool{'\t': true, '\r': true, '\n': true, ' ': true}
+
+// skipSpace is skipWhitespace for a complete text, it also skips
+// the comments and the JSON5 whitespace if relaxed.
+func skipSpace(data []byte, offset int, relaxed bool) int {
+	offset = skipWhitespace(data, offset)
+	if !relaxed || offset == len(data) {
+		return offset
+	}
+	offset = skipComments(data, offset)
+	if bytes.HasPrefix(data[offset:], bytesLineComment) {
+		// a line comment ends at EOF
+		return len(data)
+	}
+	return offset
+}
+
+// skipComments returns the offset of the first byte since offset
+// which is neither a JSON5 whitespace nor in a comment. If data
+// ends in a comment, returns the start of the comment, so it
+// could be skipped again when more data is appended.
+func skipComments(data []byte, offset int) int {
+	size := len(data)
+	for offset < size {
+		switch c := data[offset]; c {
+		case '\t', '\n', '\v', '\f', '\r', ' ':
+			offset++
+		case '/':
+			if offset+1 == size {
+				return offset
+			}
+			end := -1
+			switch data[offset+1] {
+			case '/':
+				if i := bytes.IndexByte(data[offset+2:], '\n'); i >= 0 {
+					end = offset + 2 + i + 1
+				}
+			case '*':
+				if i := bytes.Index(data[offset+2:], bytesCommentEnd); i >= 0 {
+					end = offset + 2 + i + 2
+				}
+			default:
+				return offset
+			}
+			if end < 0 {
+				return offset
+			}
+			offset = end
+		default:
+			if c < utf8.RuneSelf {
+				return offset
+			}
+			r, n := utf8.DecodeRune(data[offset:])
+			if r != '\u2028' && r != '\u2029' && r != '\uFEFF' && !unicode.Is(unicode.Zs, r) {
+				return offset
+			}
+			offset += n
+		}
+	}
+	return offset
+}
+
+// isComment reports if data starts with a comment, or a / which
+// may be the start of a comment.
+func isComment(data []byte) bool {
+	return len(data) > 0 && data[0] == '/' && (len(data) == 1 || data[1] == '/' || data[1] == '*')
+}
@@ -0,0 +1,33 @@
+package cheapjson
+
+// Span is the range of a value in the input, recorded if the
+// Spans option is set. The offsets of the values read by a
+// Decoder, a LineReader or an IncrementalParser are in the
+// whole stream, and the offsets of a UTF-16 or UTF-32 text are
+// in the text transcoded to UTF-8.
+type Span struct {
+	// the offset of the first byte of the value,
+	// and the offset after the last byte
+	Start int
+	End   int
+	// the range of the key with the quotes if the value is a
+	// field of an object, else both 0
+	KeyStart int
+	KeyEnd   int
+}
+
+// Span returns the span of the value in the input, ok is
+// false if the value is not parsed with the Spans option.
+func (v *Value) Span() (span Span, ok bool) {
+	if v.meta == nil || !v.meta.spanned {
+		return Span{}, false
+	}
+	return v.meta.span, true
+}
+
+// Position returns the line and the column of the offset in
+// data, both 1-based, the column counts the runes like the
+// ParseError.
+func Position(data []byte, offset int) (line, column int) {
+	return position(data, offset)
+}
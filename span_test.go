@@ -0,0 +1,73 @@
+package cheapjson_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+var spans = &cheapjson.ParseOptions{Spans: true}
+
+func TestSpan(t *testing.T) {
+	input := []byte("{\n  \"name\": \"a\",\n  \"list\": [1, {\"x\": null}],\n  \"empty\": {}\n}")
+	value, err := cheapjson.UnmarshalWithOptions(input, spans)
+	assert.Nil(t, err)
+	text := func(v *cheapjson.Value) string {
+		span, ok := v.Span()
+		assert.True(t, ok)
+		return string(input[span.Start:span.End])
+	}
+	key := func(v *cheapjson.Value) string {
+		span, _ := v.Span()
+		return string(input[span.KeyStart:span.KeyEnd])
+	}
+	assert.Equal(t, string(input), text(value))
+	assert.Equal(t, `"a"`, text(value.Get("name")))
+	assert.Equal(t, `"name"`, key(value.Get("name")))
+	assert.Equal(t, `[1, {"x": null}]`, text(value.Get("list")))
+	assert.Equal(t, `1`, text(value.Get("list", "0")))
+	assert.Equal(t, ``, key(value.Get("list", "0")))
+	assert.Equal(t, `{"x": null}`, text(value.Get("list", "1")))
+	assert.Equal(t, `null`, text(value.Get("list", "1", "x")))
+	assert.Equal(t, `"x"`, key(value.Get("list", "1", "x")))
+	assert.Equal(t, `{}`, text(value.Get("empty")))
+
+	span, _ := value.Get("list", "1", "x").Span()
+	line, column := cheapjson.Position(input, span.Start)
+	assert.Equal(t, 3, line)
+	assert.Equal(t, 21, column)
+
+	value, err = cheapjson.Unmarshal(input)
+	assert.Nil(t, err)
+	_, ok := value.Get("name").Span()
+	assert.False(t, ok)
+
+	// the offsets in a stream
+	stream := "[1]\n{\"a\": true}\n"
+	decoder := cheapjson.NewDecoderWithOptions(strings.NewReader(stream), spans)
+	_, err = decoder.Decode()
+	assert.Nil(t, err)
+	value, err = decoder.Decode()
+	assert.Nil(t, err)
+	span, _ = value.Get("a").Span()
+	assert.Equal(t, cheapjson.Span{Start: 10, End: 14, KeyStart: 5, KeyEnd: 8}, span)
+
+	reader := cheapjson.NewLineReaderWithOptions(strings.NewReader(stream), spans)
+	_, err = reader.Next()
+	assert.Nil(t, err)
+	value, err = reader.Next()
+	assert.Nil(t, err)
+	span, _ = value.Get("a").Span()
+	assert.Equal(t, 10, span.Start)
+
+	p := cheapjson.NewIncrementalParser(spans)
+	done, err := p.Feed([]byte(stream))
+	assert.True(t, done)
+	done, err = p.Feed(nil)
+	assert.True(t, done)
+	assert.Nil(t, err)
+	span, _ = p.Result().Get("a").Span()
+	assert.Equal(t, 10, span.Start)
+}
@@ -0,0 +1,989 @@
+package cheapjson
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	expectValue            = []string{"{", "[", "[0-9]", "-", "t", "f", "n", "\""}
+	expectEOF              = []string{"EOF"}
+	expectArrayValueOrEnd  = []string{"value", "]"}
+	expectArrayEndOrComma  = []string{",", "]"}
+	expectColon            = []string{":"}
+	expectObjectEndOrComma = []string{",", "}"}
+	expectObjectKeyOrEnd   = []string{"\"", "}"}
+	expectQuote            = []string{"\""}
+	expectEscape           = []string{"\"", "\\", "/", "b", "f", "n", "r", "t", "u"}
+	expectHex              = []string{"[0-9a-fA-F]"}
+	expectBackslash        = []string{"\\"}
+	expectU                = []string{"u", "U"}
+	expectLowSurrogate     = []string{"[dc00-dfff]"}
+	expectHighSurrogate    = []string{"[0000-dbff]", "[e000-ffff]"}
+	expectEscapedControl   = []string{"escaped control character"}
+	expectUTF8             = []string{"valid UTF-8"}
+	expectDigit            = []string{"[0-9]"}
+	expectFraction         = []string{".", "e", "E"}
+	expectNull             = []string{"null"}
+	expectTrue             = []string{"true"}
+	expectFalse            = []string{"false"}
+	expectInfinity         = []string{"Infinity"}
+	expectNaN              = []string{"NaN"}
+	expectEscapedNewline   = []string{"escaped line terminator"}
+	bytesTrue              = []byte{'r', 'u', 'e'}
+	bytesFalse             = []byte{'a', 'l', 's', 'e'}
+	bytesNull              = []byte{'u', 'l', 'l'}
+	bytesInfinity          = []byte("Infinity")
+	bytesNaN               = []byte("NaN")
+)
+
+const (
+	// start of a value
+	stateNone = iota
+	stateString
+	// after [ must be a value or ]
+	stateArrayValueOrEnd
+	// after a value, must be a , or ]
+	stateArrayEndOrComma
+	// after a {, must be a key string or }
+	stateObjectKeyOrEnd
+	// after a key string must be a :
+	stateObjectColon
+	// after a : must be a value
+	// after a value, must be , or }
+	stateObjectEndOrComma
+	// after a , must be key string
+	stateObjectKey
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind int
+
+const (
+	TokenNone TokenKind = iota
+	TokenBeginObject
+	TokenEndObject
+	TokenBeginArray
+	TokenEndArray
+	TokenKey
+	TokenString
+	TokenNumber
+	TokenTrue
+	TokenFalse
+	TokenNull
+)
+
+var tokenNames = []string{
+	"None",
+	"BeginObject",
+	"EndObject",
+	"BeginArray",
+	"EndArray",
+	"Key",
+	"String",
+	"Number",
+	"True",
+	"False",
+	"Null",
+}
+
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(tokenNames) {
+		return "TokenKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return tokenNames[k]
+}
+
+// Token is a lexical token of a JSON text.
+type Token struct {
+	Kind TokenKind
+	// the offset of the first byte of the token in the input,
+	// and the offset after the last byte
+	Offset int
+	End    int
+	// the decoded content of a Key or String, or the literal text
+	// of a Number. It may refer to the input or to the buffer of
+	// the tokenizer, so it is only valid until the next call.
+	Value []byte
+	// the number has a fraction or an exponent
+	float bool
+	// the number is only valid in JSON5, such as 0x1F and +1
+	relaxed bool
+}
+
+// an open container of the tokenizer
+type frame struct {
+	state int
+	// the index of the current element of an array, or the
+	// count of the keys read of an object
+	index int
+	// the raw current key of an object, without the quotes
+	keyStart int
+	keyEnd   int
+}
+
+// Tokenizer reads the tokens of a JSON text one by one without
+// building any Value, such as to count the records or to pull a
+// single field. After the first value, it continues to read the
+// next value if there is any, so it could read concatenated values.
+type Tokenizer struct {
+	data   []byte
+	offset int
+	opts   *ParseOptions
+	stack  []frame
+	// the buffer for the strings contain escapes
+	buf     []byte
+	value   []byte
+	float   bool
+	relaxed bool
+	// the count of the values read
+	nodes int
+	// reading a scalar value, rather than a container
+	inValue bool
+	// record the syntax errors in errs and continue, see
+	// UnmarshalRecover
+	recover bool
+	errs    []*ParseError
+	// check the escapes of the strings without decoding them,
+	// see Validate, t.value is the raw string then
+	scanOnly bool
+	// the count of the containers to close by resync
+	unwind int
+	// the first error, the tokenizer stops at it
+	err error
+}
+
+// NewTokenizer returns a tokenizer reads data without any limit.
+func NewTokenizer(data []byte) *Tokenizer {
+	return NewTokenizerWithOptions(data, nil)
+}
+
+// NewTokenizerWithOptions returns a tokenizer reads data
+// with the limits of opts.
+func NewTokenizerWithOptions(data []byte, opts *ParseOptions) *Tokenizer {
+	t := &Tokenizer{}
+	t.reset(data, opts)
+	return t
+}
+
+func (t *Tokenizer) reset(data []byte, opts *ParseOptions) {
+	if opts == nil {
+		opts = &defaultOptions
+	}
+	t.data = data
+	t.offset = 0
+	t.opts = opts
+	t.stack = t.stack[:0]
+	t.value = nil
+	t.nodes = 0
+	t.inValue = false
+	t.errs = nil
+	t.unwind = 0
+	t.err = nil
+	if opts.MaxBytes > 0 && len(data) > opts.MaxBytes {
+		t.err = exceeded("MaxBytes", opts.MaxBytes, opts.MaxBytes, data, "$")
+	} else {
+		t.data, t.offset, t.err = decodeText(data, opts)
+	}
+}
+
+// Next returns the next token, or io.EOF if there is no more
+// token. Any other error is a *ParseError, and the tokenizer
+// stops at it.
+func (t *Tokenizer) Next() (Token, error) {
+	if t.err != nil {
+		return Token{}, t.err
+	}
+	for {
+		if t.unwind > 0 {
+			return t.close(), nil
+		}
+		tok, err := t.next()
+		if err != nil && t.recovering(err) {
+			t.resync()
+			continue
+		}
+		if err != nil {
+			t.err = err
+		}
+		return tok, err
+	}
+}
+
+// Skip skips the next value. If the value is an object or an
+// array, the whole subtree is skipped by matching the brackets,
+// which is much cheaper than reading its tokens, but the skipped
+// data is only checked for the brackets and the quotes. If the
+// next token is a key, the key and its value are skipped, and if
+// the current container has no more value, its end is skipped.
+func (t *Tokenizer) Skip() error {
+	tok, err := t.Next()
+	if err != nil {
+		return err
+	}
+	if tok.Kind == TokenKey {
+		if tok, err = t.Next(); err != nil {
+			return err
+		}
+	}
+	_, err = t.skipValue(tok)
+	return err
+}
+
+// skipValue skips the rest of the value starts with tok like
+// Skip, and returns the end of the value.
+func (t *Tokenizer) skipValue(tok Token) (int, error) {
+	if tok.Kind != TokenBeginObject && tok.Kind != TokenBeginArray {
+		return tok.End, nil
+	}
+	end := scanEnd(t.data, tok.Offset, t.opts.Relaxed)
+	if end < 0 {
+		if tok.Kind == TokenBeginObject {
+			t.err = t.unexpected(expectObjectEndOrComma, len(t.data))
+		} else {
+			t.err = t.unexpected(expectArrayEndOrComma, len(t.data))
+		}
+		return 0, t.err
+	}
+	t.stack = t.stack[:len(t.stack)-1]
+	t.offset = end
+	return end, nil
+}
+
+// Depth returns the count of the open objects and arrays.
+func (t *Tokenizer) Depth() int {
+	return len(t.stack)
+}
+
+// Offset returns the offset of the next byte to read.
+func (t *Tokenizer) Offset() int {
+	return t.offset
+}
+
+// Path returns the JSONPath of the value being read, such
+// as $.orders[12].price.
+func (t *Tokenizer) Path() string {
+	var out strings.Builder
+	out.WriteByte('$')
+	for i := range t.stack {
+		f := &t.stack[i]
+		inValue := i < len(t.stack)-1 || t.inValue
+		switch f.state {
+		case stateArrayValueOrEnd, stateArrayEndOrComma:
+			if inValue && f.index >= 0 {
+				out.WriteByte('[')
+				out.WriteString(strconv.Itoa(f.index))
+				out.WriteByte(']')
+			}
+		case stateObjectColon, stateObjectEndOrComma:
+			if !inValue && f.state != stateObjectColon {
+				break
+			}
+			key := t.key(f)
+			if isIdentifier(key) {
+				out.WriteByte('.')
+				out.WriteString(key)
+			} else {
+				out.WriteByte('[')
+				out.WriteString(strconv.Quote(key))
+				out.WriteByte(']')
+			}
+		}
+	}
+	return out.String()
+}
+
+// key returns the current key of the object frame.
+func (t *Tokenizer) key(f *frame) string {
+	raw := t.data[f.keyStart:f.keyEnd]
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw)
+	}
+	k := Tokenizer{data: t.data, opts: t.opts}
+	var err error
+	if t.opts.Relaxed {
+		_, err = k.readRelaxedString(f.keyStart, t.data[f.keyStart-1])
+	} else {
+		_, err = k.readString(f.keyStart)
+	}
+	if err != nil {
+		return string(raw)
+	}
+	return string(k.value)
+}
+
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		if c == '_' || c == '$' || unicode.IsLetter(c) || (i > 0 && unicode.IsDigit(c)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func (t *Tokenizer) unexpected(expect []string, offset int) error {
+	return unexpected(expect, offset, t.data, t.Path())
+}
+
+func (t *Tokenizer) exceeded(limit string, max, offset int) error {
+	return exceeded(limit, max, offset, t.data, t.Path())
+}
+
+// exceedElements returns the MaxElements error at the element
+// being read, so its index is in the path.
+func (t *Tokenizer) exceedElements(offset int) error {
+	t.inValue = true
+	return t.exceeded("MaxElements", t.opts.MaxElements, offset)
+}
+
+func (t *Tokenizer) next() (Token, error) {
+	data := t.data
+	size := len(data)
+	for {
+		// any loop start should check the whitespace
+		t.offset = skipSpace(data, t.offset, t.opts.Relaxed)
+		offset := t.offset
+		top := len(t.stack) - 1
+		if top < 0 {
+			if offset == size {
+				return Token{}, io.EOF
+			}
+			return t.readValue()
+		}
+		curr := &t.stack[top]
+		switch curr.state {
+		case stateArrayValueOrEnd:
+			if offset == size {
+				return Token{}, t.unexpected(expectArrayValueOrEnd, offset)
+			}
+			if data[offset] == ']' {
+				return t.pop(TokenEndArray), nil
+			}
+			curr.state = stateArrayEndOrComma
+			curr.index++
+			if t.opts.MaxElements > 0 && curr.index >= t.opts.MaxElements {
+				return Token{}, t.exceedElements(offset)
+			}
+			return t.readValue()
+		case stateArrayEndOrComma:
+			if offset == size {
+				return Token{}, t.unexpected(expectArrayEndOrComma, offset)
+			}
+			switch data[offset] {
+			case ']':
+				return t.pop(TokenEndArray), nil
+			case ',':
+				t.offset = skipSpace(data, offset+1, t.opts.Relaxed)
+				if t.opts.Relaxed && t.offset < size && data[t.offset] == ']' {
+					// trailing comma
+					return t.pop(TokenEndArray), nil
+				}
+				curr.index++
+				if t.opts.MaxElements > 0 && curr.index >= t.opts.MaxElements {
+					return Token{}, t.exceedElements(t.offset)
+				}
+				return t.readValue()
+			default:
+				return Token{}, t.unexpected(expectArrayEndOrComma, offset)
+			}
+		case stateObjectColon:
+			if offset == size || data[offset] != ':' {
+				return Token{}, t.unexpected(expectColon, offset)
+			}
+			curr.state = stateObjectEndOrComma
+			t.offset = skipSpace(data, offset+1, t.opts.Relaxed)
+			return t.readValue()
+		case stateObjectEndOrComma:
+			if offset == size {
+				return Token{}, t.unexpected(expectObjectEndOrComma, offset)
+			}
+			switch data[offset] {
+			case ',':
+				curr.state = stateObjectKey
+				t.offset++
+				continue
+			case '}':
+				return t.pop(TokenEndObject), nil
+			default:
+				return Token{}, t.unexpected(expectObjectEndOrComma, offset)
+			}
+		case stateObjectKeyOrEnd:
+			if offset == size {
+				return Token{}, t.unexpected(expectObjectKeyOrEnd, offset)
+			}
+			if data[offset] == '}' {
+				return t.pop(TokenEndObject), nil
+			}
+			return t.readKey(curr, expectObjectKeyOrEnd)
+		default:
+			// stateObjectKey
+			if t.opts.Relaxed && offset < size && data[offset] == '}' {
+				// trailing comma
+				return t.pop(TokenEndObject), nil
+			}
+			return t.readKey(curr, expectQuote)
+		}
+	}
+}
+
+// pop closes the current container at t.offset.
+func (t *Tokenizer) pop(kind TokenKind) Token {
+	t.stack = t.stack[:len(t.stack)-1]
+	t.offset++
+	return Token{Kind: kind, Offset: t.offset - 1, End: t.offset}
+}
+
+// readKey reads the key starts at t.offset of the object,
+// reports expect if there is no key.
+func (t *Tokenizer) readKey(curr *frame, expect []string) (Token, error) {
+	data := t.data
+	offset := t.offset
+	if offset == len(data) {
+		return Token{}, t.unexpected(expect, offset)
+	}
+	// the end of an unquoted key
+	ident := -1
+	if data[offset] != '"' && (!t.opts.Relaxed || data[offset] != '\'') {
+		if !t.opts.Relaxed {
+			return Token{}, t.unexpected(expect, offset)
+		}
+		if ident = readIdentifier(data, offset); ident == offset {
+			return Token{}, t.unexpected(expect, offset)
+		}
+	}
+	curr.index++
+	if t.opts.MaxKeys > 0 && curr.index > t.opts.MaxKeys {
+		return Token{}, t.exceeded("MaxKeys", t.opts.MaxKeys, offset)
+	}
+	var end int
+	var err error
+	if ident >= 0 {
+		end = ident
+		t.value = data[offset:end]
+		if t.opts.MaxStringLen > 0 && len(t.value) > t.opts.MaxStringLen {
+			return Token{}, t.exceeded("MaxStringLen", t.opts.MaxStringLen, offset)
+		}
+		curr.keyStart = offset
+		curr.keyEnd = end
+	} else {
+		if t.opts.Relaxed {
+			end, err = t.readRelaxedString(offset+1, data[offset])
+		} else {
+			end, err = t.readString(offset + 1)
+		}
+		if err != nil {
+			return Token{}, err
+		}
+		curr.keyStart = offset + 1
+		curr.keyEnd = end - 1
+	}
+	curr.state = stateObjectColon
+	t.offset = end
+	return Token{Kind: TokenKey, Offset: offset, End: end, Value: t.value}, nil
+}
+
+// readValue reads the value starts at t.offset.
+func (t *Tokenizer) readValue() (Token, error) {
+	data := t.data
+	size := len(data)
+	offset := t.offset
+	t.inValue = true
+	if offset == size {
+		return Token{}, t.unexpected(expectValue, offset)
+	}
+	t.nodes++
+	if t.opts.MaxNodes > 0 && t.nodes > t.opts.MaxNodes {
+		return Token{}, t.exceeded("MaxNodes", t.opts.MaxNodes, offset)
+	}
+	tok := Token{Offset: offset}
+	switch data[offset] {
+	case '{':
+		if t.opts.MaxDepth > 0 && len(t.stack) >= t.opts.MaxDepth {
+			return Token{}, t.exceeded("MaxDepth", t.opts.MaxDepth, offset)
+		}
+		t.stack = append(t.stack, frame{state: stateObjectKeyOrEnd})
+		tok.Kind = TokenBeginObject
+		offset++
+	case '[':
+		if t.opts.MaxDepth > 0 && len(t.stack) >= t.opts.MaxDepth {
+			return Token{}, t.exceeded("MaxDepth", t.opts.MaxDepth, offset)
+		}
+		t.stack = append(t.stack, frame{state: stateArrayValueOrEnd, index: -1})
+		tok.Kind = TokenBeginArray
+		offset++
+	case '"':
+		var end int
+		var err error
+		if t.opts.Relaxed {
+			end, err = t.readRelaxedString(offset+1, '"')
+		} else {
+			end, err = t.readString(offset + 1)
+		}
+		if err != nil {
+			return Token{}, err
+		}
+		tok.Kind = TokenString
+		tok.Value = t.value
+		offset = end
+	case '0', '1', '2', '3', '4',
+		'5', '6', '7', '8', '9', '-':
+		var end int
+		var err error
+		if t.opts.Relaxed {
+			end, err = t.readRelaxedNumber(offset)
+			tok.relaxed = t.relaxed
+		} else {
+			end, err = t.readNumber(offset)
+		}
+		if err != nil {
+			return Token{}, err
+		}
+		tok.Kind = TokenNumber
+		tok.Value = data[offset:end]
+		tok.float = t.float
+		offset = end
+	case 'n':
+		offset++
+		if size < offset+3 {
+			return Token{}, t.unexpected([]string{string(bytesNull[size-offset])}, size)
+		}
+		if !bytes.Equal(data[offset:offset+3], bytesNull) {
+			return Token{}, t.unexpected(expectNull, offset-1)
+		}
+		tok.Kind = TokenNull
+		offset += 3
+	case 't':
+		offset++
+		if size < offset+3 {
+			return Token{}, t.unexpected([]string{string(bytesTrue[size-offset])}, size)
+		}
+		if !bytes.Equal(data[offset:offset+3], bytesTrue) {
+			return Token{}, t.unexpected(expectTrue, offset-1)
+		}
+		tok.Kind = TokenTrue
+		offset += 3
+	case 'f':
+		offset++
+		if size < offset+4 {
+			return Token{}, t.unexpected([]string{string(bytesFalse[size-offset])}, size)
+		}
+		if !bytes.Equal(data[offset:offset+4], bytesFalse) {
+			return Token{}, t.unexpected(expectFalse, offset-1)
+		}
+		tok.Kind = TokenFalse
+		offset += 4
+	case '\'':
+		if !t.opts.Relaxed {
+			return Token{}, t.unexpected(expectValue, offset)
+		}
+		end, err := t.readRelaxedString(offset+1, '\'')
+		if err != nil {
+			return Token{}, err
+		}
+		tok.Kind = TokenString
+		tok.Value = t.value
+		offset = end
+	case '+', '.', 'I', 'N':
+		if !t.opts.Relaxed {
+			return Token{}, t.unexpected(expectValue, offset)
+		}
+		end, err := t.readRelaxedNumber(offset)
+		if err != nil {
+			return Token{}, err
+		}
+		tok.Kind = TokenNumber
+		tok.Value = data[offset:end]
+		tok.float = t.float
+		tok.relaxed = t.relaxed
+		offset = end
+	default:
+		return Token{}, t.unexpected(expectValue, offset)
+	}
+	t.inValue = false
+	t.offset = offset
+	tok.End = offset
+	return tok, nil
+}
+
+// readNumber reads the number starts at offset, returns the end
+// of the number, and t.float reports if it is a float literal.
+func (t *Tokenizer) readNumber(offset int) (int, error) {
+	data := t.data
+	size := len(data)
+	t.float = false
+	// get negative
+	if data[offset] == '-' {
+		offset++
+	}
+	start := offset
+	for ; offset < size && data[offset] >= '0' && data[offset] <= '9'; offset++ {
+	}
+	// count of integer part
+	if offset == start {
+		// this will occur when start with -
+		return offset, t.unexpected(expectDigit, offset)
+	}
+	if data[start] == '0' && offset-start != 1 {
+		// 0 MUST only one
+		return offset, t.unexpected(expectFraction, start+1)
+	}
+	if offset < size && data[offset] == '.' {
+		// has decimal
+		offset++
+		start = offset
+		for ; offset < size && data[offset] >= '0' && data[offset] <= '9'; offset++ {
+		}
+		if offset == start {
+			// MUST contains decimal
+			return offset, t.unexpected(expectDigit, offset)
+		}
+		t.float = true
+	}
+	if offset < size && (data[offset] == 'e' || data[offset] == 'E') {
+		// has exponent
+		offset++
+		if offset < size && (data[offset] == '-' || data[offset] == '+') {
+			offset++
+		}
+		start = offset
+		for ; offset < size && data[offset] >= '0' && data[offset] <= '9'; offset++ {
+		}
+		if offset == start {
+			return offset, t.unexpected(expectDigit, offset)
+		}
+		// There do not need to check the leading 0 according to the spec
+		t.float = true
+	}
+	return offset, nil
+}
+
+// readString reads the string starts after the quote at offset,
+// returns the offset after the closing quote. The decoded content
+// is kept in t.value, which refers to the input if the string
+// has no escape.
+func (t *Tokenizer) readString(offset int) (int, error) {
+	data := t.data
+	size := len(data)
+	start := offset
+	for ; offset < size; offset++ {
+		switch data[offset] {
+		case '"':
+			t.value = data[start:offset]
+			if t.opts.MaxStringLen > 0 && len(t.value) > t.opts.MaxStringLen {
+				return offset, t.exceeded("MaxStringLen", t.opts.MaxStringLen, start-1)
+			}
+			return offset + 1, nil
+		case '\\':
+			if t.scanOnly {
+				return t.scanEscapedString(start, offset)
+			}
+			return t.readEscapedString(start, offset)
+		case 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+			16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31:
+			return offset, t.unexpected(expectEscapedControl, offset)
+		default:
+			if data[offset] < utf8.RuneSelf || t.opts.InvalidUTF8 == UTF8Pass {
+				continue
+			}
+			n := validRune(data, offset)
+			if n == 0 {
+				if t.opts.InvalidUTF8 == UTF8Reject {
+					return offset, t.unexpected(expectUTF8, offset)
+				}
+				// replace it in the buffer
+				if t.scanOnly {
+					return t.scanEscapedString(start, offset)
+				}
+				return t.readEscapedString(start, offset)
+			}
+			offset += n - 1
+		}
+	}
+	return offset, t.unexpected(expectQuote, offset)
+}
+
+// validRune returns the size of the UTF-8 char at offset, or 0
+// if it is invalid.
+func validRune(data []byte, offset int) int {
+	r, n := utf8.DecodeRune(data[offset:])
+	if r == utf8.RuneError && n == 1 {
+		return 0
+	}
+	return n
+}
+
+// appendRune appends the non ASCII char at offset to buf by the
+// InvalidUTF8 option, returns the offset of its last byte.
+func (t *Tokenizer) appendRune(buf []byte, offset int) ([]byte, int, error) {
+	if t.opts.InvalidUTF8 == UTF8Pass {
+		return append(buf, t.data[offset]), offset, nil
+	}
+	n := validRune(t.data, offset)
+	if n > 0 {
+		return append(buf, t.data[offset:offset+n]...), offset + n - 1, nil
+	}
+	if t.opts.InvalidUTF8 == UTF8Reject {
+		return buf, offset, t.unexpected(expectUTF8, offset)
+	}
+	return append(buf, "\uFFFD"...), offset, nil
+}
+
+// plainEnd returns the end of the bytes from offset which are
+// copied as is, the non ASCII bytes are checked by appendRune
+// unless InvalidUTF8 is UTF8Pass.
+func (t *Tokenizer) plainEnd(offset int) int {
+	data := t.data
+	pass := t.opts.InvalidUTF8 == UTF8Pass
+	for ; offset < len(data); offset++ {
+		c := data[offset]
+		if c == '"' || c == '\\' || c < 0x20 || (c >= utf8.RuneSelf && !pass) {
+			break
+		}
+	}
+	return offset
+}
+
+// readEscapedString continues readString from the first
+// backslash at offset, and decodes the string into t.buf.
+func (t *Tokenizer) readEscapedString(start, offset int) (int, error) {
+	data := t.data
+	size := len(data)
+	buf := append(t.buf[:0], data[start:offset]...)
+	var err error
+	for ; offset < size; offset++ {
+		switch data[offset] {
+		case '"':
+			t.buf = buf
+			t.value = buf
+			if t.opts.MaxStringLen > 0 && len(t.value) > t.opts.MaxStringLen {
+				return offset, t.exceeded("MaxStringLen", t.opts.MaxStringLen, start-1)
+			}
+			return offset + 1, nil
+		case '\\':
+			offset++
+			if offset == size {
+				return offset, t.unexpected(expectEscape, offset)
+			}
+			switch data[offset] {
+			case 'U', 'u':
+				if buf, offset, err = t.readUnicode(buf, offset+1); err != nil {
+					return offset, err
+				}
+				offset--
+			case 't':
+				buf = append(buf, '\t')
+			case 'r':
+				buf = append(buf, '\r')
+			case 'n':
+				buf = append(buf, '\n')
+			case '"':
+				buf = append(buf, '"')
+			case '\\':
+				buf = append(buf, '\\')
+			case '/':
+				buf = append(buf, '/')
+			case 'b':
+				buf = append(buf, 0x08)
+			case 'f':
+				buf = append(buf, 0x0C)
+			default:
+				return offset, t.unexpected(expectEscape, offset)
+			}
+		case 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+			16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31:
+			return offset, t.unexpected(expectEscapedControl, offset)
+		default:
+			// copy the plain bytes up to the next special one at once
+			end := t.plainEnd(offset)
+			if end > offset {
+				buf = append(buf, data[offset:end]...)
+				offset = end - 1
+			} else if buf, offset, err = t.appendRune(buf, offset); err != nil {
+				return offset, err
+			}
+		}
+	}
+	t.buf = buf
+	return offset, t.unexpected(expectQuote, offset)
+}
+
+// scanEscapedString is readEscapedString without decoding, it
+// checks the escapes and counts the size of the decoded string.
+func (t *Tokenizer) scanEscapedString(start, offset int) (int, error) {
+	data := t.data
+	size := len(data)
+	n := offset - start
+	var code int
+	var err error
+	for ; offset < size; offset++ {
+		switch data[offset] {
+		case '"':
+			t.value = data[start:offset]
+			if t.opts.MaxStringLen > 0 && n > t.opts.MaxStringLen {
+				return offset, t.exceeded("MaxStringLen", t.opts.MaxStringLen, start-1)
+			}
+			return offset + 1, nil
+		case '\\':
+			offset++
+			if offset == size {
+				return offset, t.unexpected(expectEscape, offset)
+			}
+			switch data[offset] {
+			case 'U', 'u':
+				if code, offset, err = t.readCode(offset + 1); err != nil {
+					return offset, err
+				}
+				n += codeSize(code)
+				offset--
+			case 't', 'r', 'n', '"', '\\', '/', 'b', 'f':
+				n++
+			default:
+				return offset, t.unexpected(expectEscape, offset)
+			}
+		case 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+			16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31:
+			return offset, t.unexpected(expectEscapedControl, offset)
+		default:
+			if data[offset] < utf8.RuneSelf || t.opts.InvalidUTF8 == UTF8Pass {
+				n++
+			} else if r := validRune(data, offset); r > 0 {
+				n += r
+				offset += r - 1
+			} else if t.opts.InvalidUTF8 == UTF8Reject {
+				return offset, t.unexpected(expectUTF8, offset)
+			} else {
+				n += len("\uFFFD")
+			}
+		}
+	}
+	return offset, t.unexpected(expectQuote, offset)
+}
+
+// readHex reads the n hex digits at offset.
+func (t *Tokenizer) readHex(offset, n int) (int, error) {
+	code, bad := parseHex(t.data, offset, n)
+	if bad >= 0 {
+		return 0, t.unexpected(expectHex, bad)
+	}
+	return code, nil
+}
+
+// parseHex parses the n hex digits at offset, returns the offset
+// of the first bad digit, or -1 if they are valid.
+func parseHex(data []byte, offset, n int) (int, int) {
+	if len(data) < offset+n {
+		return 0, len(data)
+	}
+	code := 0
+	for end := offset + n; offset < end; offset++ {
+		switch data[offset] {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			code = code<<4 | int(data[offset]-0x30)
+		case 'a', 'b', 'c', 'd', 'e', 'f':
+			code = code<<4 | int(data[offset]-0x57)
+		case 'A', 'B', 'C', 'D', 'E', 'F':
+			code = code<<4 | int(data[offset]-0x37)
+		default:
+			return 0, offset
+		}
+	}
+	return code, -1
+}
+
+// readUnicode reads the \u escape whose hex digits start at
+// offset and appends its char to buf, see readCode.
+func (t *Tokenizer) readUnicode(buf []byte, offset int) ([]byte, int, error) {
+	code, offset, err := t.readCode(offset)
+	if err != nil {
+		return buf, offset, err
+	}
+	return appendCode(buf, code), offset, nil
+}
+
+// readCode reads the \u escape whose hex digits start at offset,
+// a UTF-16 surrogate pair is combined into one char, and an
+// unpaired surrogate is handled by the Surrogates option.
+// Returns the offset after the escape.
+func (t *Tokenizer) readCode(offset int) (int, int, error) {
+	code, err := t.readHex(offset, 4)
+	if err != nil {
+		return 0, offset, err
+	}
+	offset += 4
+	if code > 0xD7FF && code < 0xDC00 {
+		// need next utf-16 part
+		low, end, expect := t.readLowSurrogate(offset)
+		if expect == nil {
+			return (((code - 0xD800) << 10) | (low - 0xDC00)) + 0x10000, end, nil
+		}
+		if t.opts.Surrogates == SurrogateError {
+			// only positioned here, an unpaired surrogate is
+			// common in the other modes
+			return 0, end, t.unexpected(expect, end)
+		}
+	} else if code > 0xDBFF && code < 0xE000 && t.opts.Surrogates == SurrogateError {
+		return 0, offset - 4, t.unexpected(expectHighSurrogate, offset-4)
+	}
+	if code > 0xD7FF && code < 0xE000 && t.opts.Surrogates == SurrogateReplace {
+		code = utf8.RuneError
+	}
+	return code, offset, nil
+}
+
+// readLowSurrogate reads the \u escape of a low surrogate at
+// offset, returns its code and the offset after it. If there
+// is none, returns what is expected at the returned offset.
+func (t *Tokenizer) readLowSurrogate(offset int) (int, int, []string) {
+	data := t.data
+	size := len(data)
+	if offset == size || data[offset] != '\\' {
+		return 0, offset, expectBackslash
+	}
+	offset++
+	if offset == size || (data[offset] != 'U' && data[offset] != 'u') {
+		return 0, offset, expectU
+	}
+	offset++
+	low, bad := parseHex(data, offset, 4)
+	if bad >= 0 {
+		return 0, bad, expectHex
+	}
+	if low < 0xDC00 || low > 0xDFFF {
+		return 0, offset, expectLowSurrogate
+	}
+	return low, offset + 4, nil
+}
+
+// codeSize returns the size of the code point encoded by appendCode.
+func codeSize(code int) int {
+	if code < 0x0080 {
+		return 1
+	} else if code < 0x0800 {
+		return 2
+	} else if code < 0x10000 {
+		return 3
+	}
+	return 4
+}
+
+// appendCode encodes the code point to UTF-8, unlike utf8.AppendRune,
+// a surrogate is encoded as is rather than as U+FFFD, it is WTF-8.
+func appendCode(buf []byte, code int) []byte {
+	if code < 0x0080 {
+		return append(buf, byte(code))
+	} else if code < 0x0800 {
+		return append(buf, 0xC0|byte(code>>6), 0x80|byte(code&0x3F))
+	} else if code < 0x10000 {
+		return append(buf, 0xE0|byte(code>>12), 0x80|byte((code>>6)&0x3F), 0x80|byte(code&0x3F))
+	}
+	return append(buf, 0xF0|byte(code>>18), 0x80|byte((code>>12)&0x3F),
+		0x80|byte((code>>6)&0x3F), 0x80|byte(code&0x3F))
+}
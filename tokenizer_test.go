@@ -0,0 +1,91 @@
+package cheapjson_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizer(t *testing.T) {
+	input := []byte(`{"a": [1, -2.5e3, "x\ny"], "b": {"c": true, "d": false}, "e": null} []`)
+	tokenizer := cheapjson.NewTokenizer(input)
+	var kinds []cheapjson.TokenKind
+	var values []string
+	var offsets []int
+	for {
+		tok, err := tokenizer.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		kinds = append(kinds, tok.Kind)
+		values = append(values, string(tok.Value))
+		offsets = append(offsets, tok.Offset)
+	}
+	assert.Equal(t, []cheapjson.TokenKind{
+		cheapjson.TokenBeginObject,
+		cheapjson.TokenKey, cheapjson.TokenBeginArray,
+		cheapjson.TokenNumber, cheapjson.TokenNumber, cheapjson.TokenString,
+		cheapjson.TokenEndArray,
+		cheapjson.TokenKey, cheapjson.TokenBeginObject,
+		cheapjson.TokenKey, cheapjson.TokenTrue,
+		cheapjson.TokenKey, cheapjson.TokenFalse,
+		cheapjson.TokenEndObject,
+		cheapjson.TokenKey, cheapjson.TokenNull,
+		cheapjson.TokenEndObject,
+		cheapjson.TokenBeginArray, cheapjson.TokenEndArray,
+	}, kinds)
+	assert.Equal(t, []string{"", "a", "", "1", "-2.5e3", "x\ny", "", "b", "", "c", "", "d", "", "", "e", "", "", "", ""}, values)
+	assert.Equal(t, []int{0, 1, 6, 7, 10, 18, 24, 27, 32, 33, 38, 44, 49, 54, 57, 62, 66, 68, 69}, offsets)
+	assert.Equal(t, "BeginObject", cheapjson.TokenBeginObject.String())
+
+	tokenizer = cheapjson.NewTokenizer([]byte(`{"a": [1, 2`))
+	for err := error(nil); err == nil; _, err = tokenizer.Next() {
+	}
+	_, err := tokenizer.Next()
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "$.a", perr.Path)
+}
+
+func TestTokenizerSkip(t *testing.T) {
+	input := []byte(`[{"id": 1, "tags": ["a]", {"b": "}"}], "name": "x"}, {"id": 2, "name": "y"}, 3]`)
+	tokenizer := cheapjson.NewTokenizer(input)
+	tok, err := tokenizer.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, cheapjson.TokenBeginArray, tok.Kind)
+	var names []string
+	for i := 0; i < 2; i++ {
+		_, _ = tokenizer.Next()
+		for {
+			tok, err = tokenizer.Next()
+			assert.Nil(t, err)
+			if tok.Kind == cheapjson.TokenEndObject {
+				break
+			}
+			if string(tok.Value) == "name" {
+				tok, _ = tokenizer.Next()
+				names = append(names, string(tok.Value))
+			} else {
+				assert.Nil(t, tokenizer.Skip())
+			}
+		}
+	}
+	assert.Equal(t, []string{"x", "y"}, names)
+	assert.Equal(t, 1, tokenizer.Depth())
+	assert.Nil(t, tokenizer.Skip())
+	assert.Nil(t, tokenizer.Skip())
+	assert.Equal(t, 0, tokenizer.Depth())
+	_, err = tokenizer.Next()
+	assert.Equal(t, io.EOF, err)
+
+	tokenizer = cheapjson.NewTokenizer([]byte(`[[1, [2]`))
+	_, _ = tokenizer.Next()
+	err = tokenizer.Skip()
+	var perr *cheapjson.ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, 8, perr.Offset)
+}
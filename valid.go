@@ -0,0 +1,66 @@
+package cheapjson
+
+import "sync"
+
+// the tokenizers reused by Validate
+var tokenizers = sync.Pool{
+	New: func() interface{} {
+		return &Tokenizer{}
+	},
+}
+
+// Valid reports whether data is a valid JSON text.
+func Valid(data []byte) bool {
+	return Validate(data) == nil
+}
+
+// Validate checks the syntax of a JSON text without building any
+// Value, and returns the same *ParseError as Unmarshal if it is
+// invalid. The escapes of the strings are checked but not decoded,
+// and the tokenizer is reused, so it does not allocate for most
+// valid texts.
+func Validate(data []byte) error {
+	return ValidateWithOptions(data, nil)
+}
+
+// ValidateWithOptions is Validate with the options, the limits are
+// checked like UnmarshalWithOptions. The DuplicateError policy needs
+// the keys of each object, so the text is parsed with it.
+func ValidateWithOptions(data []byte, opts *ParseOptions) error {
+	if opts != nil && opts.DuplicateKeys == DuplicateError {
+		_, err := UnmarshalWithOptions(data, opts)
+		return err
+	}
+	t := tokenizers.Get().(*Tokenizer)
+	t.reset(data, opts)
+	t.scanOnly = true
+	err := drive(t, discard{})
+	if err == nil {
+		if t.offset = skipSpace(t.data, t.offset, t.opts.Relaxed); t.offset != len(t.data) {
+			err = unexpected(expectEOF, t.offset, t.data, "$")
+		}
+	}
+	// do not hold the input
+	t.data = nil
+	t.value = nil
+	tokenizers.Put(t)
+	return err
+}
+
+// discard is the Handler drops the values, it receives the
+// numbers like the valueBuilder, so the numbers out of the range
+// are reported by the Overflow option in the same way.
+type discard struct{}
+
+func (discard) OnObjectStart() error        { return nil }
+func (discard) OnKey(key string) error      { return nil }
+func (discard) OnObjectEnd() error          { return nil }
+func (discard) OnArrayStart() error         { return nil }
+func (discard) OnArrayEnd() error           { return nil }
+func (discard) OnString(value string) error { return nil }
+func (discard) OnInt(value int64) error     { return nil }
+func (discard) OnFloat(value float64) error { return nil }
+func (discard) OnUint(value uint64) error   { return nil }
+func (discard) OnNumber(text string) error  { return nil }
+func (discard) OnBool(value bool) error     { return nil }
+func (discard) OnNull() error               { return nil }
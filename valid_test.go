@@ -0,0 +1,80 @@
+package cheapjson_test
+
+import (
+	"testing"
+
+	"github.com/acrazing/cheapjson"
+	"github.com/stretchr/testify/assert"
+)
+
+// set in the race mode
+var race bool
+
+func TestValidate(t *testing.T) {
+	assert.True(t, cheapjson.Valid(normalInput))
+	assert.True(t, cheapjson.Valid(deepInput))
+	assert.True(t, cheapjson.Valid([]byte(` "a\nb" `)))
+	for _, input := range []string{
+		``,
+		`{"a": [1, tru]}`,
+		`{"a" 1}`,
+		`[1] [2]`,
+		`["\x"]`,
+		`[1e400]`,
+		`["a\u12x4"]`,
+		`["a\ud800\u0041"]`,
+		`["\q"]`,
+		"[\"a\\n\x01\"]",
+		`["a\"`,
+	} {
+		_, expected := cheapjson.Unmarshal([]byte(input))
+		err := cheapjson.Validate([]byte(input))
+		assert.NotNil(t, err, input)
+		assert.Equal(t, expected, err, input)
+		assert.False(t, cheapjson.Valid([]byte(input)), input)
+	}
+
+	for _, c := range []struct {
+		input string
+		opts  *cheapjson.ParseOptions
+	}{
+		{`[[1]]`, &cheapjson.ParseOptions{MaxDepth: 1}},
+		{`{"a": 1, "a": 2}`, &cheapjson.ParseOptions{DuplicateKeys: cheapjson.DuplicateError}},
+		{`{a: 1,}`, nil},
+		{"[\"\xff\"]", &cheapjson.ParseOptions{InvalidUTF8: cheapjson.UTF8Reject}},
+		{"[\"\\n\xff\"]", &cheapjson.ParseOptions{InvalidUTF8: cheapjson.UTF8Reject}},
+		{`["\ud83d\ude02\u00e9"]`, &cheapjson.ParseOptions{MaxStringLen: 5}},
+		{"[\"\\t\xff\xfe\"]", &cheapjson.ParseOptions{MaxStringLen: 6, InvalidUTF8: cheapjson.UTF8Replace}},
+	} {
+		_, expected := cheapjson.UnmarshalWithOptions([]byte(c.input), c.opts)
+		assert.NotNil(t, expected, c.input)
+		assert.Equal(t, expected, cheapjson.ValidateWithOptions([]byte(c.input), c.opts), c.input)
+	}
+	assert.Nil(t, cheapjson.ValidateWithOptions([]byte(`{a: 123456789012345678901234567890,}`), &cheapjson.ParseOptions{Relaxed: true, Overflow: cheapjson.OverflowFloat}))
+	assert.Nil(t, cheapjson.ValidateWithOptions([]byte(`["\ud83d\ude02\u00e9"]`), &cheapjson.ParseOptions{MaxStringLen: 6}))
+	assert.Nil(t, cheapjson.ValidateWithOptions([]byte("[\"\\t\xff\xfe\"]"), &cheapjson.ParseOptions{MaxStringLen: 7, InvalidUTF8: cheapjson.UTF8Replace}))
+
+	// the pools drop the items randomly in the race mode
+	if !race {
+		escaped := []byte(`{"a\tb": ["\u00e9\ud83d\ude02", "\"quoted\""]}`)
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = cheapjson.Valid(normalInput)
+			_ = cheapjson.Valid(escaped)
+		})
+		assert.Equal(t, 0.0, allocs)
+	}
+}
+
+func BenchmarkValidNormalInput(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cheapjson.Valid(normalInput)
+	}
+}
+
+func BenchmarkValidBigInput(b *testing.B) {
+	b.SetBytes(int64(len(bigInput)))
+	for i := 0; i < b.N; i++ {
+		cheapjson.Valid(bigInput)
+	}
+}
@@ -1,9 +1,51 @@
 package cheapjson
 
-import "strconv"
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+)
 
 type Value struct {
 	value interface{}
+	// the optional info recorded by the parser,
+	// nil unless an option asks for it
+	meta *meta
+}
+
+type meta struct {
+	// all the values of the duplicated keys of an object
+	duplicates map[string][]*Value
+	// the keys of an ordered object in the insertion order
+	keys    []string
+	ordered bool
+	// the span in the input, if the Spans option is set
+	span    Span
+	spanned bool
+}
+
+// OrderedMap is returned by Value for an ordered object,
+// it is encoded by encoding/json in the order of Keys.
+type OrderedMap struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	out := []byte{'{'}
+	for i, key := range m.Keys {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = appendString(out, key)
+		out = append(out, ':')
+		data, err := json.Marshal(m.Values[key])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data...)
+	}
+	return append(out, '}'), nil
 }
 
 type null struct{}
@@ -11,7 +53,7 @@ type null struct{}
 var NULL = null{}
 
 func NewValue() *Value {
-	return &Value{nil}
+	return &Value{}
 }
 
 func (v *Value) AsObject(value map[string]*Value) {
@@ -20,6 +62,26 @@ func (v *Value) AsObject(value map[string]*Value) {
 	} else {
 		v.value = value
 	}
+	if v.meta != nil {
+		v.meta.keys = nil
+		v.meta.ordered = false
+	}
+}
+
+// AsOrderedObject sets the value as an empty object which
+// keeps the insertion order of the keys added by AddField.
+func (v *Value) AsOrderedObject() {
+	v.value = map[string]*Value{}
+	if v.meta == nil {
+		v.meta = &meta{}
+	}
+	v.meta.keys = nil
+	v.meta.ordered = true
+}
+
+// IsOrdered reports whether the value is an ordered object.
+func (v *Value) IsOrdered() bool {
+	return v.IsObject() && v.meta != nil && v.meta.ordered
 }
 
 func (v *Value) AsArray(value []*Value) {
@@ -34,6 +96,12 @@ func (v *Value) AsInt(value int64) {
 	v.value = value
 }
 
+// AsUint sets the value as an unsigned integer, the parser
+// uses it for the positive integers greater than math.MaxInt64.
+func (v *Value) AsUint(value uint64) {
+	v.value = value
+}
+
 func (v *Value) AsFloat(value float64) {
 	v.value = value
 }
@@ -51,23 +119,65 @@ func (v *Value) AsString(value string) {
 }
 
 func (v *Value) AddField(key string) *Value {
+	value := NewValue()
+	v.addField(key, value)
+	return value
+}
+
+func (v *Value) addField(key string, value *Value) {
 	if values, ok := v.value.(map[string]*Value); ok {
-		value := NewValue()
+		if v.meta != nil && v.meta.ordered {
+			if _, ok = values[key]; !ok {
+				v.meta.keys = append(v.meta.keys, key)
+			}
+		}
 		values[key] = value
-		return value
+		return
 	}
 	panic("not a object value")
 }
 
 func (v *Value) AddElement() *Value {
+	value := NewValue()
+	v.addElement(value)
+	return value
+}
+
+func (v *Value) addElement(value *Value) {
 	if values, ok := v.value.([]*Value); ok {
-		value := NewValue()
 		v.value = append(values, value)
-		return value
+		return
 	}
 	panic("not a array value")
 }
 
+// Duplicates returns all the values of the key in the order of
+// the input, if the object is parsed with DuplicateCollect and the
+// key appears more than once, else returns nil. The field of the
+// object holds the last one.
+func (v *Value) Duplicates(key string) []*Value {
+	if v.meta == nil {
+		return nil
+	}
+	return v.meta.duplicates[key]
+}
+
+// addDuplicate records the value of a duplicated key,
+// the first is the value of the first occurrence.
+func (v *Value) addDuplicate(key string, first, value *Value) {
+	if v.meta == nil {
+		v.meta = &meta{}
+	}
+	if v.meta.duplicates == nil {
+		v.meta.duplicates = map[string][]*Value{}
+	}
+	values := v.meta.duplicates[key]
+	if values == nil {
+		values = []*Value{first}
+	}
+	v.meta.duplicates[key] = append(values, value)
+}
+
 func (v *Value) IsObject() bool {
 	switch v.value.(type) {
 	case map[string]*Value:
@@ -86,18 +196,30 @@ func (v *Value) IsArray() bool {
 	}
 }
 
+// IsInt reports whether the value is an int64, a raw number
+// is an int if its text is an integer in the int64 range.
 func (v *Value) IsInt() bool {
-	switch v.value.(type) {
+	switch value := v.value.(type) {
 	case int64:
 		return true
+	case number:
+		_, err := strconv.ParseInt(string(value), 10, 64)
+		return err == nil
 	default:
 		return false
 	}
 }
 
+// IsUint reports whether the value is a uint64, the parser only
+// uses it for the positive integers greater than math.MaxInt64.
+func (v *Value) IsUint() bool {
+	_, ok := v.value.(uint64)
+	return ok
+}
+
 func (v *Value) IsNumber() bool {
 	switch v.value.(type) {
-	case int64, float64:
+	case int64, uint64, float64, number:
 		return true
 	default:
 		return false
@@ -187,6 +309,7 @@ func (v *Value) Get(path ...string) *Value {
 // if not, will force covert to an object
 func (v *Value) Ensure(path ...string) *Value {
 	temp := v
+	var next *Value
 	var ok bool
 	var obj map[string]*Value
 	for _, field := range path {
@@ -194,9 +317,10 @@ func (v *Value) Ensure(path ...string) *Value {
 			temp.AsObject(nil)
 		}
 		if obj, ok = temp.value.(map[string]*Value); ok {
-			if temp, ok = obj[field]; !ok {
-				temp = NewValue()
-				obj[field] = temp
+			if next, ok = obj[field]; ok {
+				temp = next
+			} else {
+				temp = temp.AddField(field)
 			}
 		} else {
 			panic("any thing do not want")
@@ -205,6 +329,62 @@ func (v *Value) Ensure(path ...string) *Value {
 	return temp
 }
 
+// Keys returns the keys of an object, in the insertion order for
+// an ordered object, else in the sorted order. The keys added to
+// an ordered object through the map returned by Object rather than
+// AddField are sorted and placed at the end.
+func (v *Value) Keys() []string {
+	values := v.Object()
+	if v.meta == nil || !v.meta.ordered {
+		return sortedKeys(values)
+	}
+	keys := v.meta.keys
+	consistent := len(keys) == len(values)
+	for i := 0; consistent && i < len(keys); i++ {
+		_, consistent = values[keys[i]]
+	}
+	if !consistent {
+		seen := make(map[string]bool, len(values))
+		keys = keys[:0:0]
+		for _, key := range v.meta.keys {
+			if _, ok := values[key]; ok && !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		var rest []string
+		for key := range values {
+			if !seen[key] {
+				rest = append(rest, key)
+			}
+		}
+		sort.Strings(rest)
+		keys = append(keys, rest...)
+	}
+	// the order is not cached, Keys does not modify the value
+	return keys[:len(keys):len(keys)]
+}
+
+// Range calls fn for each field of an object in the order of
+// Keys, and stops if fn returns false.
+func (v *Value) Range(fn func(key string, value *Value) bool) {
+	values := v.Object()
+	for _, key := range v.Keys() {
+		if !fn(key, values[key]) {
+			return
+		}
+	}
+}
+
+func sortedKeys(values map[string]*Value) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (v *Value) Object() map[string]*Value {
 	if value, ok := v.value.(map[string]*Value); ok {
 		return value
@@ -223,16 +403,37 @@ func (v *Value) Int() int64 {
 	if value, ok := v.value.(int64); ok {
 		return value
 	}
+	if value, ok := v.value.(number); ok {
+		if out, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			return out
+		}
+	}
 	panic("not a int value")
 }
 
+func (v *Value) Uint() uint64 {
+	if value, ok := v.value.(uint64); ok {
+		return value
+	}
+	panic("not a uint value")
+}
+
+// Float returns the number as a float64, a raw number out of
+// the float64 range returns ±Inf.
 func (v *Value) Float() float64 {
 	if value, ok := v.value.(int64); ok {
 		return float64(value)
 	}
+	if value, ok := v.value.(uint64); ok {
+		return float64(value)
+	}
 	if value, ok := v.value.(float64); ok {
 		return float64(value)
 	}
+	if value, ok := v.value.(number); ok {
+		out, _ := strconv.ParseFloat(string(value), 64)
+		return out
+	}
 	panic("not a number value")
 }
 
@@ -250,14 +451,19 @@ func (v *Value) Value() interface{} {
 	switch v.value.(type) {
 	case null, nil:
 		return nil
-	case string, bool, int64, float64:
+	case string, bool, int64, uint64, float64:
 		return v.value
+	case number:
+		return json.Number(v.value.(number))
 	case map[string]*Value:
 		if values, ok := v.value.(map[string]*Value); ok {
 			out := map[string]interface{}{}
 			for key, value := range values {
 				out[key] = value.Value()
 			}
+			if v.meta != nil && v.meta.ordered {
+				return &OrderedMap{v.Keys(), out}
+			}
 			return out
 		}
 		return nil
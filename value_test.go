@@ -1,6 +1,7 @@
 package cheapjson_test
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 
@@ -49,3 +50,51 @@ func TestNewValue(t *testing.T) {
 	assert.Equal(t, false, object.Object()["array"].Object()["2"].IsArray())
 	assert.Equal(t, true, object.Object()["array"].Object()["2"].Object()["sub"].IsFalse())
 }
+
+func TestOrderedObject(t *testing.T) {
+	input := []byte(`{"z":1,"a":{"y":true,"b":null},"m":[{"k2":1,"k1":2}],"a":2}`)
+	value, err := cheapjson.UnmarshalWithOptions(input, &cheapjson.ParseOptions{PreserveOrder: true})
+	assert.Nil(t, err)
+	assert.True(t, value.IsOrdered())
+	assert.Equal(t, []string{"z", "a", "m"}, value.Keys())
+	assert.Equal(t, int64(2), value.Get("a").Int())
+	output, err := value.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"z":1,"a":2,"m":[{"k2":1,"k1":2}]}`, string(output))
+	output, err = json.Marshal(value.Value())
+	assert.Nil(t, err)
+	assert.Equal(t, `{"z":1,"a":2,"m":[{"k2":1,"k1":2}]}`, string(output))
+	var keys []string
+	value.Range(func(key string, field *cheapjson.Value) bool {
+		keys = append(keys, key)
+		return key != "a"
+	})
+	assert.Equal(t, []string{"z", "a"}, keys)
+
+	value.Ensure("m", "x").AsInt(1)
+	value.Ensure("c").AsInt(1)
+	value.Object()["b"] = cheapjson.NewValue()
+	delete(value.Object(), "z")
+	assert.Equal(t, []string{"a", "m", "c", "b"}, value.Keys())
+	// read only, so safe for the concurrent readers
+	done := make(chan []string)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- value.Keys()
+		}()
+	}
+	assert.Equal(t, <-done, <-done)
+	keys = append(value.Keys(), "z")
+	assert.Equal(t, []string{"a", "m", "c", "b"}, value.Keys())
+
+	value, err = cheapjson.Unmarshal(input)
+	assert.Nil(t, err)
+	assert.False(t, value.IsOrdered())
+	assert.Equal(t, []string{"a", "m", "z"}, value.Keys())
+	value.AsOrderedObject()
+	value.AddField("y")
+	value.AddField("x")
+	assert.Equal(t, []string{"y", "x"}, value.Keys())
+	value.AsObject(nil)
+	assert.False(t, value.IsOrdered())
+}